@@ -2,6 +2,13 @@ package actor
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+	"todo-app/auth"
+	"todo-app/events"
+	"todo-app/metrics"
 	"todo-app/storage"
 )
 
@@ -9,8 +16,14 @@ const (
 	CreateCmd  string = "CreateCmd"
 	UpdateCmd  string = "UpdateCmd"
 	DeleteCmd  string = "DeleteCmd"
+	RestoreCmd string = "RestoreCmd"
 	ListAllCmd string = "ListAllCmd"
 	ListCmd    string = "ListCmd"
+	SharesCmd  string = "SharesCmd"
+	ShareCmd   string = "ShareCmd"
+	UnshareCmd string = "UnshareCmd"
+	PingCmd    string = "PingCmd"
+	CheckCmd   string = "CheckCmd"
 )
 
 type Command struct {
@@ -18,75 +31,157 @@ type Command struct {
 	ID          int
 	Description string
 	Status      string
+	Owner       string
+	Created     time.Time
+	Restricted  bool
+	List        string
+	User        string
+	Role        auth.Role
 	ResultChan  chan Response
+
+	// ctx is the caller's context; claimed is shared with the waiting caller so that
+	// either the actor starts the command or the caller gives up on it, never both
+	ctx     context.Context
+	claimed *atomic.Bool
 }
 
 type Response struct {
-	Error error
-	Item  storage.Item
-	Items storage.Items
+	Error  error
+	Item   storage.Item
+	Items  storage.Items
+	Share  auth.Share
+	Shares []auth.Share
+	Health storage.Health
 }
 
 type Actor struct {
 	cmdChan chan Command
+	events  *events.Broker
+	shares  *auth.ShareStore
+	timeout time.Duration
+
+	// mu guards stopped; senders hold it for reading while they register as pending
+	mu      sync.RWMutex
+	stopped bool
+	pending sync.WaitGroup
+	done    chan struct{}
 }
 
-// NewActor creates and starts a new Actor instance.
+// ErrStopped is returned for commands sent after Stop.
+var ErrStopped = errors.New("actor stopped")
+
+var (
+	queueDepth      = metrics.NewGauge("todo_actor_queue_depth", "Commands waiting for the actor to pick them up.")
+	commandDuration = metrics.NewHistogram("todo_actor_command_duration_seconds", "Time the actor spent processing a command.", metrics.DefaultBuckets, "command")
+)
+
+// DefaultTimeout is how long a command may wait for the actor, on top of any deadline the caller's context has.
+const DefaultTimeout = 5 * time.Second
+
+// NewActor creates and starts a new Actor instance without shared lists.
 func NewActor(ctx context.Context) *Actor {
+	return NewSharedActor(ctx, nil)
+}
+
+// NewSharedActor creates and starts a new Actor instance whose users can share their lists through the share store.
+func NewSharedActor(ctx context.Context, shares *auth.ShareStore) *Actor {
 	actor := &Actor{
 		cmdChan: make(chan Command),
+		events:  events.NewBroker(events.DefaultBufferSize),
+		shares:  shares,
+		timeout: DefaultTimeout,
+		done:    make(chan struct{}),
 	}
 	go actor.run(ctx)
 	return actor
 }
 
-// run processes incoming commands sequentially.
+// run processes incoming commands sequentially until Stop closes the command channel.
 func (a *Actor) run(ctx context.Context) {
+	defer close(a.done)
 	for cmd := range a.cmdChan {
+		// skip commands whose caller has already given up
+		if !cmd.start() {
+			continue
+		}
+		started := time.Now()
+
+		// answer pings without touching storage, they only show the loop is responsive
+		if cmd.Type == PingCmd {
+			cmd.ResultChan <- Response{}
+			continue
+		}
+		// once started the command completes, logged under the caller's context
+		cmdCtx := ctx
+		if cmd.ctx != nil {
+			cmdCtx = context.WithoutCancel(cmd.ctx)
+		}
+
+		// reload storage to ensure we have the latest data
+		reloadStorage(ctx)
+
+		// check the caller's role before the command touches storage
+		if err := a.authorize(cmd); err != nil {
+			cmd.ResultChan <- Response{Error: err}
+			commandDuration.Observe(time.Since(started).Seconds(), cmd.Type)
+			continue
+		}
+
 		switch cmd.Type {
 		case CreateCmd:
-			// reload storage to ensure we have the latest data
-			reloadStorage(ctx)
-
-			// create the item
-			item, err := storage.CreateItem(ctx, cmd.Description, cmd.Status)
+			// create the item in the target list, the caller's own by default
+			item, err := storage.CreateItemFor(cmdCtx, targetList(cmd), cmd.Description, cmd.Status)
 
 			// send back result
 			if err != nil {
 				cmd.ResultChan <- Response{Error: err}
 			} else {
+				a.events.Publish(events.ItemCreated, item)
 				cmd.ResultChan <- Response{Item: item}
 			}
 
 		case UpdateCmd:
-			// reload storage to ensure we have the latest data
-			reloadStorage(ctx)
-
 			// update the item
 			item := storage.Item{ID: cmd.ID, Description: cmd.Description, Status: cmd.Status}
-			updated, err := storage.UpdateItem(ctx, item)
+			updated, err := storage.UpdateItem(cmdCtx, item)
 
 			// send back result
 			if err != nil {
 				cmd.ResultChan <- Response{Error: err}
 			} else {
+				a.events.Publish(events.ItemUpdated, updated)
 				cmd.ResultChan <- Response{Item: updated}
 			}
 
 		case DeleteCmd:
-			// reload storage to ensure we have the latest data
-			reloadStorage(ctx)
+			// keep a copy of the item for the change feed
+			deleted, _ := storage.GetItemByID(cmd.ID)
 
 			// delete the item
-			err := storage.DeleteItem(ctx, cmd.ID)
+			err := storage.DeleteItem(cmdCtx, cmd.ID)
+			if err == nil {
+				a.events.Publish(events.ItemDeleted, deleted)
+			}
 			// send back result
 			cmd.ResultChan <- Response{Error: err}
-		case ListAllCmd:
-			// reload storage to ensure we have the latest data
-			reloadStorage(ctx)
+		case RestoreCmd:
+			// put the deleted item back under its ID in its list
+			item := storage.Item{ID: cmd.ID, Description: cmd.Description, Status: cmd.Status, Created: cmd.Created, Owner: targetList(cmd)}
+			restored, err := storage.RestoreItem(cmdCtx, item)
 
-			// get all items
+			// send back result
+			if err != nil {
+				cmd.ResultChan <- Response{Error: err}
+			} else {
+				a.events.Publish(events.ItemCreated, restored)
+				cmd.ResultChan <- Response{Item: restored}
+			}
+		case ListAllCmd:
+			// get all items visible to the caller
 			items, err := storage.GetAllItems()
+			if err == nil && cmd.Restricted {
+				items, err = a.visibleItems(items, cmd.Owner)
+			}
 
 			// send back result
 			if err != nil {
@@ -95,9 +190,6 @@ func (a *Actor) run(ctx context.Context) {
 				cmd.ResultChan <- Response{Items: items}
 			}
 		case ListCmd:
-			// reload storage to ensure we have the latest data
-			reloadStorage(ctx)
-
 			// get the item by ID
 			item, err := storage.GetItemByID(cmd.ID)
 
@@ -107,15 +199,110 @@ func (a *Actor) run(ctx context.Context) {
 			} else {
 				cmd.ResultChan <- Response{Item: item}
 			}
+		case SharesCmd:
+			// list who the target list is shared with
+			shares, err := a.shares.Shares(targetList(cmd))
+			cmd.ResultChan <- Response{Shares: shares, Error: err}
+		case ShareCmd:
+			// give the user a role on the target list
+			share, err := a.shares.Set(targetList(cmd), cmd.User, cmd.Role)
+			cmd.ResultChan <- Response{Share: share, Error: err}
+		case UnshareCmd:
+			// take the user's role on the target list away
+			err := a.shares.Remove(targetList(cmd), cmd.User)
+			cmd.ResultChan <- Response{Error: err}
+		case CheckCmd:
+			// check the data file while holding the actor, the only writer
+			cmd.ResultChan <- Response{Health: storage.Check()}
+		default:
+			cmd.ResultChan <- Response{Error: errUnknownCommand}
+		}
+		commandDuration.Observe(time.Since(started).Seconds(), cmd.Type)
+	}
+}
+
+// Stop refuses new commands, waits for the commands already sent to be processed, and ends the actor goroutine.
+// It returns early with the context's error if draining takes too long.
+func (a *Actor) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.stopped {
+		a.stopped = true
+		go func() {
+			a.pending.Wait()
+			close(a.cmdChan)
+		}()
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send passes a command from the caller in ctx to the actor goroutine and waits for its response.
+// It gives up with the context's error when ctx ends or the command timeout passes first;
+// a command given up on is never applied.
+func (a *Actor) send(ctx context.Context, cmd Command) Response {
+	a.mu.RLock()
+	if a.stopped {
+		a.mu.RUnlock()
+		return Response{Error: ErrStopped}
+	}
+	a.pending.Add(1)
+	a.mu.RUnlock()
+	defer a.pending.Done()
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	cmd = withCaller(ctx, cmd)
+	cmd.ctx = ctx
+	cmd.claimed = new(atomic.Bool)
+	// buffered so the actor never blocks on a caller that has left
+	cmd.ResultChan = make(chan Response, 1)
+
+	queueDepth.Add(1)
+	select {
+	case a.cmdChan <- cmd:
+		queueDepth.Add(-1)
+	case <-ctx.Done():
+		queueDepth.Add(-1)
+		return Response{Error: ctx.Err()}
+	}
+	select {
+	case result := <-cmd.ResultChan:
+		return result
+	case <-ctx.Done():
+		if cmd.claimed.CompareAndSwap(false, true) {
+			return Response{Error: ctx.Err()}
 		}
+		// the actor started the command before we gave up, so its result is on the way
+		return <-cmd.ResultChan
+	}
+}
+
+// start claims the command for the actor, reporting false when the caller has already given up on it.
+// Commands sent without a caller context always run.
+func (cmd Command) start() bool {
+	if cmd.claimed == nil {
+		return true
+	}
+	if cmd.ctx.Err() != nil {
+		return false
 	}
+	return cmd.claimed.CompareAndSwap(false, true)
+}
+
+// Events returns the broker that receives an event for every processed mutation.
+func (a *Actor) Events() *events.Broker {
+	return a.events
 }
 
 // Create creates a new item with the given description and status.
 func (a *Actor) Create(ctx context.Context, description string, status string) (storage.Item, error) {
-	resultChan := make(chan Response)
-	a.cmdChan <- Command{Type: CreateCmd, Description: description, Status: status, ResultChan: resultChan}
-	result := <-resultChan
+	result := a.send(ctx, Command{Type: CreateCmd, Description: description, Status: status})
 	if result.Error != nil {
 		return storage.Item{}, result.Error
 	}
@@ -124,9 +311,7 @@ func (a *Actor) Create(ctx context.Context, description string, status string) (
 
 // Update updates an existing item with the given ID, description, and status.
 func (a *Actor) Update(ctx context.Context, id int, description string, status string) (storage.Item, error) {
-	resultChan := make(chan Response)
-	a.cmdChan <- Command{Type: UpdateCmd, ID: id, Description: description, Status: status, ResultChan: resultChan}
-	result := <-resultChan
+	result := a.send(ctx, Command{Type: UpdateCmd, ID: id, Description: description, Status: status})
 	if result.Error != nil {
 		return storage.Item{}, result.Error
 	}
@@ -135,37 +320,90 @@ func (a *Actor) Update(ctx context.Context, id int, description string, status s
 
 // Delete deletes the item with the given ID.
 func (a *Actor) Delete(ctx context.Context, id int) error {
-	resultChan := make(chan Response)
-	a.cmdChan <- Command{Type: DeleteCmd, ID: id, ResultChan: resultChan}
-	result := <-resultChan
+	result := a.send(ctx, Command{Type: DeleteCmd, ID: id})
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
 }
 
+// Restore puts a deleted item back with its ID, list and creation time, which needs the editor role on the list.
+func (a *Actor) Restore(ctx context.Context, item storage.Item) (storage.Item, error) {
+	result := a.send(ctx, Command{Type: RestoreCmd, ID: item.ID, List: item.Owner, Description: item.Description, Status: item.Status, Created: item.Created})
+	if result.Error != nil {
+		return storage.Item{}, result.Error
+	}
+	return result.Item, nil
+}
+
 // ListAll returns all items.
 func (a *Actor) ListAll(ctx context.Context) (storage.Items, error) {
-	resultChan := make(chan Response)
-	a.cmdChan <- Command{Type: ListAllCmd, ResultChan: resultChan}
-	result := <-resultChan
+	result := a.send(ctx, Command{Type: ListAllCmd})
 	if result.Error != nil {
 		return storage.Items{}, result.Error
 	}
 	return result.Items, nil
 }
 
+// CreateIn creates a new item in another user's list, which needs the editor role on it.
+// An empty list creates the item in the caller's own list.
+func (a *Actor) CreateIn(ctx context.Context, list string, description string, status string) (storage.Item, error) {
+	result := a.send(ctx, Command{Type: CreateCmd, List: list, Description: description, Status: status})
+	if result.Error != nil {
+		return storage.Item{}, result.Error
+	}
+	return result.Item, nil
+}
+
 // List returns the item with the given ID.
 func (a *Actor) List(ctx context.Context, id int) (storage.Item, error) {
-	resultChan := make(chan Response)
-	a.cmdChan <- Command{Type: ListCmd, ID: id, ResultChan: resultChan}
-	result := <-resultChan
+	result := a.send(ctx, Command{Type: ListCmd, ID: id})
 	if result.Error != nil {
 		return storage.Item{}, result.Error
 	}
 	return result.Item, nil
 }
 
+// Shares returns who the list is shared with; an empty list is the caller's own.
+func (a *Actor) Shares(ctx context.Context, list string) ([]auth.Share, error) {
+	result := a.send(ctx, Command{Type: SharesCmd, List: list})
+	return result.Shares, result.Error
+}
+
+// Share gives a user a role on the list; an empty list is the caller's own.
+func (a *Actor) Share(ctx context.Context, list string, user string, role auth.Role) (auth.Share, error) {
+	result := a.send(ctx, Command{Type: ShareCmd, List: list, User: user, Role: role})
+	return result.Share, result.Error
+}
+
+// Unshare takes a user's role on the list away; an empty list is the caller's own.
+func (a *Actor) Unshare(ctx context.Context, list string, user string) error {
+	result := a.send(ctx, Command{Type: UnshareCmd, List: list, User: user})
+	return result.Error
+}
+
+// Ping returns once the actor goroutine has picked up a command, showing it is not stuck.
+func (a *Actor) Ping(ctx context.Context) error {
+	return a.send(ctx, Command{Type: PingCmd}).Error
+}
+
+// CheckStorage reports the state of the data file, checked from the actor goroutine.
+// The error is set when the actor could not run the check in time.
+func (a *Actor) CheckStorage(ctx context.Context) (storage.Health, error) {
+	result := a.send(ctx, Command{Type: CheckCmd})
+	return result.Health, result.Error
+}
+
+// withCaller stamps the command with the authenticated user from ctx.
+// Commands without an identity come from local callers such as the CLI and are not restricted.
+func withCaller(ctx context.Context, cmd Command) Command {
+	if identity, ok := auth.FromContext(ctx); ok {
+		cmd.Owner = identity.User
+		cmd.Restricted = !identity.IsAdmin()
+	}
+	return cmd
+}
+
 // Helper to reload storage before every read
 func reloadStorage(ctx context.Context) {
 	if storageFile := storage.GetDataFile(); storageFile != "" {
@@ -2,10 +2,16 @@ package actor
 
 import (
 	"context"
+	"errors"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"todo-app/auth"
+	"todo-app/events"
+	"todo-app/metrics"
 	"todo-app/storage"
 )
 
@@ -236,6 +242,36 @@ func TestActor_Delete(t *testing.T) {
 	}
 }
 
+// TestActor_Restore tests that a deleted item comes back with its ID and creation time.
+func TestActor_Restore(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	actor := NewActor(ctx)
+
+	created, err := actor.Create(ctx, "To Be Restored", "in_progress")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := actor.Create(ctx, "Keeps the next ID taken", "not_started"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := actor.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	restored, err := actor.Restore(ctx, created)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got, _ := actor.List(ctx, created.ID); restored != created || got != created {
+		t.Errorf("Expected %+v restored, got %+v and %+v", created, restored, got)
+	}
+	if _, err := actor.Restore(ctx, created); !errors.Is(err, storage.ErrItemExists) {
+		t.Errorf("Expected ErrItemExists restoring twice, got %v", err)
+	}
+}
+
 // TestActor_Delete_NotFound tests deleting a non-existent item.
 func TestActor_Delete_NotFound(t *testing.T) {
 	_, cleanup := setupTestStorage(t)
@@ -520,3 +556,306 @@ func TestActor_Concurrency_SequentialOperations(t *testing.T) {
 		t.Error("Expected error after deletion, got nil")
 	}
 }
+
+// TestActor_PublishesEvents tests that mutations are published to the change feed.
+func TestActor_PublishesEvents(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	actor := NewActor(ctx)
+	_, ch, _ := actor.Events().Subscribe(0)
+	defer actor.Events().Unsubscribe(ch)
+
+	created, err := actor.Create(ctx, "Event Item", "not_started")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := actor.Update(ctx, created.ID, "Event Item Updated", "in_progress"); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := actor.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	expected := []string{events.ItemCreated, events.ItemUpdated, events.ItemDeleted}
+	for _, eventType := range expected {
+		select {
+		case event := <-ch:
+			if event.Type != eventType || event.Item.ID != created.ID {
+				t.Errorf("Expected %s for item %d, got %+v", eventType, created.ID, event)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %s", eventType)
+		}
+	}
+}
+
+// TestActor_FailedMutationNotPublished tests that failed commands do not produce events.
+func TestActor_FailedMutationNotPublished(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	actor := NewActor(ctx)
+
+	_ = actor.Delete(ctx, 999)
+	if actor.Events().LastID() != 0 {
+		t.Errorf("Expected no events, got last ID %d", actor.Events().LastID())
+	}
+}
+
+// TestActor_OwnerIsolation tests that users only see and change their own items.
+func TestActor_OwnerIsolation(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	alice := auth.WithIdentity(context.Background(), auth.Identity{User: "alice"})
+	bob := auth.WithIdentity(context.Background(), auth.Identity{User: "bob"})
+	admin := auth.WithIdentity(context.Background(), auth.Identity{User: "root", Scopes: []string{auth.ScopeAdmin}})
+
+	item, err := actor.Create(alice, "Alice's item", "not_started")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if item.Owner != "alice" {
+		t.Errorf("Expected owner alice, got %q", item.Owner)
+	}
+	if _, err := actor.Create(bob, "Bob's item", "not_started"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// bob cannot see or change alice's item
+	if _, err := actor.List(bob, item.ID); err == nil {
+		t.Error("Expected bob to be denied alice's item")
+	}
+	if _, err := actor.Update(bob, item.ID, "Hijacked", "is_finished"); err == nil {
+		t.Error("Expected bob's update to fail")
+	}
+	if err := actor.Delete(bob, item.ID); err == nil {
+		t.Error("Expected bob's delete to fail")
+	}
+	if items, _ := actor.ListAll(bob); len(items) != 1 {
+		t.Errorf("Expected bob to see 1 item, got %d", len(items))
+	}
+
+	// admins and local callers see everything
+	if items, _ := actor.ListAll(admin); len(items) != 2 {
+		t.Errorf("Expected admin to see 2 items, got %d", len(items))
+	}
+	if items, _ := actor.ListAll(context.Background()); len(items) != 2 {
+		t.Errorf("Expected local caller to see 2 items, got %d", len(items))
+	}
+
+	// the owner can still change the item
+	if _, err := actor.Update(alice, item.ID, "Alice's item updated", "in_progress"); err != nil {
+		t.Errorf("Expected alice's update to succeed: %v", err)
+	}
+}
+
+// TestActor_StopDrainsCommands tests that Stop lets commands already sent finish and refuses new ones.
+func TestActor_StopDrainsCommands(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	actor := NewActor(ctx)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, refused := 0, 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := actor.Create(ctx, "Draining", "not_started")
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				created++
+			case ErrStopped:
+				refused++
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := actor.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	wg.Wait()
+
+	// every accepted command was processed and saved
+	if created+refused != 20 {
+		t.Errorf("Expected 20 results, got %d", created+refused)
+	}
+	if items, err := storage.GetAllItems(); created > 0 && (err != nil || len(items) != created) {
+		t.Errorf("Expected %d saved items, got %d (%v)", created, len(items), err)
+	}
+
+	if _, err := actor.Create(ctx, "Too late", "not_started"); err != ErrStopped {
+		t.Errorf("Expected ErrStopped after Stop, got %v", err)
+	}
+	if err := actor.Stop(ctx); err != nil {
+		t.Errorf("Expected a second Stop to succeed, got %v", err)
+	}
+}
+
+// TestActor_StopTimeout tests that Stop gives up when the context ends before the actor drains.
+func TestActor_StopTimeout(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+
+	// a sender that registered but never gets its command processed keeps the actor from draining
+	actor.pending.Add(1)
+	defer actor.pending.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := actor.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// busy keeps the actor goroutine occupied until the returned function is called.
+func busy(t *testing.T, actor *Actor) func() {
+	t.Helper()
+	block := make(chan Response)
+	actor.cmdChan <- Command{Type: ListAllCmd, ResultChan: block}
+	return func() { <-block }
+}
+
+// TestActor_CancelledContext tests that a command from a caller that already gave up is not applied.
+func TestActor_CancelledContext(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := actor.Create(ctx, "Never", "not_started"); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if items, _ := actor.ListAll(context.Background()); len(items) != 0 {
+		t.Errorf("Expected no items, got %d", len(items))
+	}
+}
+
+// TestActor_DeadlineWhileBusy tests that a caller stops waiting for a busy actor and its command is dropped.
+func TestActor_DeadlineWhileBusy(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	release := busy(t, actor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := actor.Create(ctx, "Too slow", "not_started"); err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	release()
+
+	if items, _ := actor.ListAll(context.Background()); len(items) != 0 {
+		t.Errorf("Expected the timed out create not to be applied, got %d items", len(items))
+	}
+}
+
+// TestActor_CommandTimeout tests that commands get a deadline even when the caller's context has none.
+func TestActor_CommandTimeout(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	actor.timeout = 20 * time.Millisecond
+	release := busy(t, actor)
+	defer release()
+
+	start := time.Now()
+	if _, err := actor.ListAll(context.Background()); err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the command to time out quickly, took %v", elapsed)
+	}
+}
+
+// TestActor_AbandonedCommandSkipped tests that a command already queued is skipped once its caller has given up on it.
+func TestActor_AbandonedCommandSkipped(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+
+	// the caller claimed the command when it gave up, so the actor must not start it
+	claimed := new(atomic.Bool)
+	claimed.Store(true)
+	resultChan := make(chan Response, 1)
+	actor.cmdChan <- Command{Type: CreateCmd, Description: "Abandoned", Status: "not_started", ResultChan: resultChan, ctx: context.Background(), claimed: claimed}
+
+	if items, _ := actor.ListAll(context.Background()); len(items) != 0 {
+		t.Errorf("Expected the abandoned create not to be applied, got %d items", len(items))
+	}
+	select {
+	case result := <-resultChan:
+		t.Errorf("Expected no response for an abandoned command, got %+v", result)
+	default:
+	}
+}
+
+// TestActor_RecordsMetrics tests that processed commands show up in the command duration histogram.
+func TestActor_RecordsMetrics(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	if _, err := actor.Create(context.Background(), "Measured", "not_started"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var out strings.Builder
+	metrics.Default.WriteText(&out)
+	if !strings.Contains(out.String(), `todo_actor_command_duration_seconds_count{command="CreateCmd"} `) {
+		t.Errorf("expected a CreateCmd duration sample:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "todo_actor_queue_depth 0\n") {
+		t.Errorf("expected an empty queue:\n%s", out.String())
+	}
+}
+
+// TestActor_PingAndCheckStorage tests the health commands on a working and a stuck actor.
+func TestActor_PingAndCheckStorage(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	if err := actor.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if _, err := actor.Create(context.Background(), "Saved", "not_started"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	health, err := actor.CheckStorage(context.Background())
+	if err != nil || health.File != nil || health.LastSaveErr != nil || health.LastSave.IsZero() {
+		t.Errorf("Expected healthy storage, got %+v (%v)", health, err)
+	}
+
+	// a restricted caller may run the check too
+	if _, err := actor.CheckStorage(auth.WithIdentity(context.Background(), auth.Identity{User: "bob"})); err != nil {
+		t.Errorf("Expected CheckStorage to be allowed for users, got %v", err)
+	}
+
+	release := busy(t, actor)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := actor.Ping(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected a stuck actor to time out, got %v", err)
+	}
+}
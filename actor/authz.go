@@ -0,0 +1,112 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"todo-app/auth"
+	"todo-app/storage"
+)
+
+var (
+	// ErrForbidden is returned when the caller can see a list but their role does not allow the command.
+	ErrForbidden = errors.New("permission denied")
+	// ErrListNotFound is returned for lists the caller has no role on, so their existence is not revealed.
+	ErrListNotFound = errors.New("list not found")
+
+	errSharingDisabled = errors.New("sharing is not enabled")
+	errUnknownCommand  = errors.New("unknown command")
+)
+
+// authorize checks a command against the caller's role on the list it targets.
+// It runs in the actor goroutine before every command, so no command type can reach storage unchecked.
+// Users own their list and hold every role on it; admins and local callers are not restricted.
+func (a *Actor) authorize(cmd Command) error {
+	switch cmd.Type {
+	case SharesCmd, ShareCmd, UnshareCmd:
+		if a.shares == nil {
+			return errSharingDisabled
+		}
+	}
+	if !cmd.Restricted {
+		return nil
+	}
+
+	switch cmd.Type {
+	case CreateCmd, RestoreCmd:
+		return a.require(cmd, targetList(cmd), auth.RoleEditor, ErrListNotFound)
+	case UpdateCmd, DeleteCmd:
+		item, err := storage.GetItemByID(cmd.ID)
+		if err != nil {
+			return err
+		}
+		return a.require(cmd, item.Owner, auth.RoleEditor, storage.ErrItemNotFound)
+	case ListCmd:
+		item, err := storage.GetItemByID(cmd.ID)
+		if err != nil {
+			return err
+		}
+		return a.require(cmd, item.Owner, auth.RoleViewer, storage.ErrItemNotFound)
+	case ListAllCmd:
+		// the result is filtered to the lists the caller can see
+		return nil
+	case CheckCmd:
+		// reports no items, only whether storage works
+		return nil
+	case SharesCmd, ShareCmd, UnshareCmd:
+		return a.require(cmd, targetList(cmd), auth.RoleAdmin, ErrListNotFound)
+	default:
+		return errUnknownCommand
+	}
+}
+
+// require returns an error unless the caller holds at least the required role on the list.
+// Callers without any role get notFound rather than a permission error.
+func (a *Actor) require(cmd Command, list string, required auth.Role, notFound error) error {
+	if list != "" && list == cmd.Owner {
+		return nil
+	}
+	role := a.roleOn(list, cmd.Owner)
+	if role == "" {
+		return notFound
+	}
+	if !role.Allows(required) {
+		return fmt.Errorf("%w: %s role required on the list of %s, you have %s", ErrForbidden, required, list, role)
+	}
+	return nil
+}
+
+// roleOn returns the user's role on another user's list.
+func (a *Actor) roleOn(list string, user string) auth.Role {
+	if a.shares == nil || list == "" {
+		return ""
+	}
+	return a.shares.Role(list, user)
+}
+
+// visibleItems returns the items in the user's own list and in the lists shared with them.
+func (a *Actor) visibleItems(items storage.Items, user string) (storage.Items, error) {
+	visible := storage.Items{}
+	roles := map[string]auth.Role{}
+	for id, item := range items {
+		role, ok := roles[item.Owner]
+		if !ok {
+			role = a.roleOn(item.Owner, user)
+			roles[item.Owner] = role
+		}
+		if item.Owner == user || role != "" {
+			visible[id] = item
+		}
+	}
+	if len(visible) == 0 {
+		return storage.Items{}, storage.ErrNoItems
+	}
+	return visible, nil
+}
+
+// targetList returns the list a command applies to, defaulting to the caller's own.
+func targetList(cmd Command) string {
+	if cmd.List != "" {
+		return cmd.List
+	}
+	return cmd.Owner
+}
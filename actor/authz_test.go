@@ -0,0 +1,167 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"todo-app/auth"
+	"todo-app/storage"
+)
+
+// setupSharedActor starts an actor with a share store in a temp folder.
+func setupSharedActor(t *testing.T) (*Actor, *auth.ShareStore) {
+	shares, err := auth.OpenShareStore(filepath.Join(t.TempDir(), "shares.json"))
+	if err != nil {
+		t.Fatalf("OpenShareStore failed: %v", err)
+	}
+	return NewSharedActor(context.Background(), shares), shares
+}
+
+// TestActor_SharedListRoles tests that each role allows exactly its operations on a shared list.
+func TestActor_SharedListRoles(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor, _ := setupSharedActor(t)
+	alice := auth.WithIdentity(context.Background(), auth.Identity{User: "alice"})
+	bob := auth.WithIdentity(context.Background(), auth.Identity{User: "bob"})
+
+	item, err := actor.Create(alice, "Shared item", "not_started")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// without a role bob cannot tell the item exists
+	if _, err := actor.Update(bob, item.ID, "Edited", "in_progress"); !errors.Is(err, storage.ErrItemNotFound) {
+		t.Errorf("Expected ErrItemNotFound without a role, got %v", err)
+	}
+
+	// viewers can read but not change
+	if _, err := actor.Share(alice, "", "bob", auth.RoleViewer); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	if _, err := actor.List(bob, item.ID); err != nil {
+		t.Errorf("Expected viewer to see the item: %v", err)
+	}
+	if items, _ := actor.ListAll(bob); len(items) != 1 {
+		t.Errorf("Expected viewer to list 1 item, got %d", len(items))
+	}
+	if _, err := actor.Update(bob, item.ID, "Edited", "in_progress"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for viewer update, got %v", err)
+	}
+	if err := actor.Delete(bob, item.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for viewer delete, got %v", err)
+	}
+	if _, err := actor.CreateIn(bob, "alice", "New", "not_started"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for viewer create, got %v", err)
+	}
+
+	// editors can change items but not share the list
+	if _, err := actor.Share(alice, "", "bob", auth.RoleEditor); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	if _, err := actor.Update(bob, item.ID, "Edited", "in_progress"); err != nil {
+		t.Errorf("Expected editor update to succeed: %v", err)
+	}
+	created, err := actor.CreateIn(bob, "alice", "Added by bob", "not_started")
+	if err != nil || created.Owner != "alice" {
+		t.Errorf("Expected editor to create in alice's list, got %+v %v", created, err)
+	}
+	if _, err := actor.Share(bob, "alice", "carol", auth.RoleViewer); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for editor share, got %v", err)
+	}
+
+	// list admins can share
+	if _, err := actor.Share(alice, "", "bob", auth.RoleAdmin); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	if _, err := actor.Share(bob, "alice", "carol", auth.RoleViewer); err != nil {
+		t.Errorf("Expected list admin to share: %v", err)
+	}
+	shares, err := actor.Shares(alice, "")
+	if err != nil || len(shares) != 2 {
+		t.Errorf("Expected 2 shares, got %+v %v", shares, err)
+	}
+}
+
+// TestActor_RestoreSharedItem tests that an editor restores an item of a shared list into that list,
+// and that a viewer cannot restore it.
+func TestActor_RestoreSharedItem(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor, _ := setupSharedActor(t)
+	alice := auth.WithIdentity(context.Background(), auth.Identity{User: "alice"})
+	bob := auth.WithIdentity(context.Background(), auth.Identity{User: "bob"})
+
+	item, err := actor.Create(alice, "Shared item", "not_started")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := actor.Share(alice, "", "bob", auth.RoleEditor); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	if err := actor.Delete(bob, item.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	restored, err := actor.Restore(bob, item)
+	if err != nil || restored.Owner != "alice" || restored.ID != item.ID {
+		t.Errorf("Expected the item back in alice's list, got %+v %v", restored, err)
+	}
+
+	if _, err := actor.Share(alice, "", "bob", auth.RoleViewer); err != nil {
+		t.Fatalf("Share failed: %v", err)
+	}
+	if err := actor.Delete(alice, item.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := actor.Restore(bob, item); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for viewer restore, got %v", err)
+	}
+	carol := auth.WithIdentity(context.Background(), auth.Identity{User: "carol"})
+	if _, err := actor.Restore(carol, item); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound without a role, got %v", err)
+	}
+}
+
+// TestActor_UnknownListHidden tests that lists the caller has no role on are reported as not found.
+func TestActor_UnknownListHidden(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor, _ := setupSharedActor(t)
+	bob := auth.WithIdentity(context.Background(), auth.Identity{User: "bob"})
+
+	if _, err := actor.CreateIn(bob, "alice", "Sneaky", "not_started"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+	if _, err := actor.Shares(bob, "alice"); !errors.Is(err, ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+}
+
+// TestActor_UnknownCommandDenied tests that command types without an authorization rule are rejected.
+func TestActor_UnknownCommandDenied(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor, _ := setupSharedActor(t)
+	resultChan := make(chan Response)
+	actor.cmdChan <- Command{Type: "DropAllCmd", Owner: "bob", Restricted: true, ResultChan: resultChan}
+	if result := <-resultChan; !errors.Is(result.Error, errUnknownCommand) {
+		t.Errorf("Expected errUnknownCommand, got %v", result.Error)
+	}
+}
+
+// TestActor_SharingDisabled tests that share commands fail on an actor without a share store.
+func TestActor_SharingDisabled(t *testing.T) {
+	_, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	actor := NewActor(context.Background())
+	alice := auth.WithIdentity(context.Background(), auth.Identity{User: "alice"})
+	if _, err := actor.Share(alice, "", "bob", auth.RoleViewer); !errors.Is(err, errSharingDisabled) {
+		t.Errorf("Expected errSharingDisabled, got %v", err)
+	}
+}
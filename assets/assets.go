@@ -0,0 +1,34 @@
+package assets
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// embedded holds the templates, static files and OpenAPI document compiled into the binary.
+//
+//go:embed templates static openapi.json
+var embedded embed.FS
+
+// FS returns the asset file system: the embedded assets, or the given directory when set
+// so templates and static files can be edited without rebuilding during development.
+func FS(dir string) (fs.FS, error) {
+	if dir == "" {
+		return embedded, nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("assets dir %s is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+// Embedded returns the assets compiled into the binary.
+func Embedded() fs.FS {
+	return embedded
+}
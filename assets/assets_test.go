@@ -0,0 +1,48 @@
+package assets
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAssets_EmbeddedContents tests that templates and static files are embedded.
+func TestAssets_EmbeddedContents(t *testing.T) {
+	fsys, err := FS("")
+	if err != nil {
+		t.Fatalf("FS failed: %v", err)
+	}
+	for _, name := range []string{"templates/list.html", "templates/confirm.html", "templates/layout.html", "templates/login.html", "static/about/index.html", "static/css/app.css", "static/js/list.js", "openapi.json"} {
+		if _, err := fs.Stat(fsys, name); err != nil {
+			t.Errorf("Expected %s to be embedded: %v", name, err)
+		}
+	}
+}
+
+// TestAssets_Directory tests serving assets from a directory on disk.
+func TestAssets_Directory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "templates", "list.html"), []byte("dev"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	fsys, err := FS(dir)
+	if err != nil {
+		t.Fatalf("FS failed: %v", err)
+	}
+	data, err := fs.ReadFile(fsys, "templates/list.html")
+	if err != nil || string(data) != "dev" {
+		t.Errorf("Expected file from directory, got %q, %v", data, err)
+	}
+}
+
+// TestAssets_MissingDirectory tests that a missing override directory is reported.
+func TestAssets_MissingDirectory(t *testing.T) {
+	if _, err := FS(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
@@ -0,0 +1,32 @@
+// Keeps the /list table in sync with other browsers by refetching it whenever /ws reports a change.
+(function () {
+  var stale = false;
+  function editing() {
+    var active = document.activeElement;
+    return active && active.closest && active.closest("#todos form");
+  }
+  function refresh() {
+    if (editing()) { stale = true; return; }
+    stale = false;
+    fetch(location.href, {credentials: "same-origin"}).then(function (response) {
+      return response.text();
+    }).then(function (html) {
+      var fresh = new DOMParser().parseFromString(html, "text/html").querySelector("#todos tbody");
+      var current = document.querySelector("#todos tbody");
+      if (fresh && current) { current.replaceWith(fresh); }
+    });
+  }
+  document.addEventListener("focusout", function () {
+    setTimeout(function () { if (stale) { refresh(); } }, 0);
+  });
+  function connect() {
+    var scheme = location.protocol === "https:" ? "wss://" : "ws://";
+    var socket = new WebSocket(scheme + location.host + "/ws");
+    socket.onmessage = function (message) {
+      var data = JSON.parse(message.data);
+      if (data.type === "event") { refresh(); }
+    };
+    socket.onclose = function () { setTimeout(connect, 2000); };
+  }
+  connect();
+})();
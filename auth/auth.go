@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ScopeAdmin lets a user see and change every item, and manage server-wide settings such as webhooks.
+	ScopeAdmin string = "admin"
+	// ScopeMetrics lets a token read /metrics and nothing else, for Prometheus scrapers.
+	ScopeMetrics string = "metrics"
+)
+
+// tokenPrefix marks bearer tokens issued by this application.
+const tokenPrefix string = "todo_"
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	ErrInvalidUser   = errors.New("user name cannot be empty or contain whitespace")
+)
+
+type Identity struct {
+	User   string
+	Scopes []string
+}
+
+type Token struct {
+	ID      string    `json:"id"`
+	User    string    `json:"user"`
+	Hash    string    `json:"hash"`
+	Scopes  []string  `json:"scopes,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type TokenStore struct {
+	mu     sync.Mutex
+	file   jsonFile
+	tokens []Token
+}
+
+type identityKey struct{}
+
+// IsAdmin reports whether the identity has the admin scope.
+func (i Identity) IsAdmin() bool {
+	return slices.Contains(i.Scopes, ScopeAdmin)
+}
+
+// CanReadMetrics reports whether the identity may read the metrics endpoint.
+func (i Identity) CanReadMetrics() bool {
+	return i.IsAdmin() || slices.Contains(i.Scopes, ScopeMetrics)
+}
+
+// MetricsOnly reports whether the identity is limited to the metrics endpoint.
+func (i Identity) MetricsOnly() bool {
+	return slices.Contains(i.Scopes, ScopeMetrics) && !i.IsAdmin()
+}
+
+// WithIdentity returns a context carrying the authenticated identity.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the authenticated identity, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// OpenTokenStore loads the hashed tokens from the given json file, which is created on first write.
+func OpenTokenStore(file string) (*TokenStore, error) {
+	store := &TokenStore{file: jsonFile{name: file}}
+	if err := store.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Create issues a new token for the user and returns the secret, which is only available now; only its hash is stored.
+func (s *TokenStore) Create(user string, scopes []string) (string, Token, error) {
+	if user == "" || strings.ContainsFunc(user, isSpace) {
+		return "", Token{}, ErrInvalidUser
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return "", Token{}, err
+	}
+
+	id := randomHex(6)
+	secret := tokenPrefix + id + "_" + randomHex(24)
+	token := Token{ID: id, User: user, Hash: hashSecret(secret), Scopes: scopes, Created: time.Now().UTC()}
+	s.tokens = append(s.tokens, token)
+	if err := s.save(); err != nil {
+		s.tokens = s.tokens[:len(s.tokens)-1]
+		return "", Token{}, err
+	}
+	return secret, token, nil
+}
+
+// Revoke deletes the token with the given ID.
+func (s *TokenStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(s.tokens, func(token Token) bool { return token.ID == id })
+	if index < 0 {
+		return ErrTokenNotFound
+	}
+	s.tokens = slices.Delete(s.tokens, index, index+1)
+	return s.save()
+}
+
+// List returns the stored tokens.
+func (s *TokenStore) List() ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(s.tokens), nil
+}
+
+// Authenticate returns the identity owning the bearer token secret.
+// Tokens created or revoked from the CLI while the server runs are picked up on the next call.
+func (s *TokenStore) Authenticate(secret string) (Identity, bool) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return Identity{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return Identity{}, false
+	}
+
+	hash := hashSecret(secret)
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) == 1 {
+			return Identity{User: token.User, Scopes: token.Scopes}, true
+		}
+	}
+	return Identity{}, false
+}
+
+// reloadIfChanged re-reads the token file when another process has modified it; callers hold the lock.
+func (s *TokenStore) reloadIfChanged() error {
+	tokens, err := reload(&s.file, s.tokens)
+	s.tokens = tokens
+	return err
+}
+
+// save writes the tokens; callers hold the lock.
+func (s *TokenStore) save() error {
+	return s.file.save(s.tokens)
+}
+
+// hashSecret returns the hex SHA-256 of a token secret; tokens are high-entropy so a fast hash is sufficient.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes as a hex string.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isSpace reports whether r is an ASCII whitespace character.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
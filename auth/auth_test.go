@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAuth_CreateAuthenticateRevoke tests the token lifecycle.
+func TestAuth_CreateAuthenticateRevoke(t *testing.T) {
+	store, err := OpenTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	if err != nil {
+		t.Fatalf("OpenTokenStore failed: %v", err)
+	}
+
+	secret, token, err := store.Create("alice", []string{ScopeAdmin})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	identity, ok := store.Authenticate(secret)
+	if !ok || identity.User != "alice" || !identity.IsAdmin() {
+		t.Fatalf("unexpected identity: %+v %v", identity, ok)
+	}
+	if _, ok := store.Authenticate(secret + "x"); ok {
+		t.Error("expected wrong secret to fail")
+	}
+
+	if err := store.Revoke(token.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, ok := store.Authenticate(secret); ok {
+		t.Error("expected revoked token to fail")
+	}
+	if err := store.Revoke(token.ID); err != ErrTokenNotFound {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+// TestAuth_OnlyHashStored tests that the secret itself never reaches the token file.
+func TestAuth_OnlyHashStored(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tokens.json")
+	store, _ := OpenTokenStore(file)
+	secret, _, err := store.Create("alice", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Errorf("token file leaks the secret: %s", data)
+	}
+}
+
+// TestAuth_ReloadFromFile tests that tokens created by another process are picked up.
+func TestAuth_ReloadFromFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tokens.json")
+	server, _ := OpenTokenStore(file)
+	cli, _ := OpenTokenStore(file)
+
+	secret, _, err := cli.Create("bob", nil)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if identity, ok := server.Authenticate(secret); !ok || identity.User != "bob" {
+		t.Errorf("expected server to see new token, got %+v %v", identity, ok)
+	}
+}
+
+// TestAuth_InvalidUser tests that empty or whitespace user names are rejected.
+func TestAuth_InvalidUser(t *testing.T) {
+	store, _ := OpenTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	for _, user := range []string{"", "two words"} {
+		if _, _, err := store.Create(user, nil); err != ErrInvalidUser {
+			t.Errorf("expected ErrInvalidUser for %q, got %v", user, err)
+		}
+	}
+}
+
+// TestAuth_MetricsScope tests that the metrics scope allows scraping and limits a token to it unless it is also admin.
+func TestAuth_MetricsScope(t *testing.T) {
+	tests := []struct {
+		scopes      []string
+		readMetrics bool
+		metricsOnly bool
+	}{
+		{nil, false, false},
+		{[]string{ScopeMetrics}, true, true},
+		{[]string{ScopeAdmin}, true, false},
+		{[]string{ScopeMetrics, ScopeAdmin}, true, false},
+	}
+	for _, tt := range tests {
+		identity := Identity{User: "prometheus", Scopes: tt.scopes}
+		if identity.CanReadMetrics() != tt.readMetrics || identity.MetricsOnly() != tt.metricsOnly {
+			t.Errorf("%v: expected CanReadMetrics %v and MetricsOnly %v", tt.scopes, tt.readMetrics, tt.metricsOnly)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+// jsonFile is a json file shared between the server and CLI processes.
+// It remembers the modification time of the last read so changes made by another process are picked up.
+type jsonFile struct {
+	name     string
+	modified time.Time
+}
+
+// reload returns the records in the file if it changed since the last read, otherwise current.
+// A missing file holds no records.
+func reload[T any](f *jsonFile, current []T) ([]T, error) {
+	info, err := os.Stat(f.name)
+	if errors.Is(err, os.ErrNotExist) {
+		f.modified = time.Time{}
+		return nil, nil
+	}
+	if err != nil {
+		return current, err
+	}
+	if info.ModTime().Equal(f.modified) {
+		return current, nil
+	}
+
+	data, err := os.ReadFile(f.name)
+	if err != nil {
+		return current, err
+	}
+	var records []T
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return current, err
+		}
+	}
+	f.modified = info.ModTime()
+	return records, nil
+}
+
+// save atomically writes the records with owner-only permissions.
+func (f *jsonFile) save(records any) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.name); err != nil {
+		return err
+	}
+	if info, err := os.Stat(f.name); err == nil {
+		f.modified = info.ModTime()
+	}
+	return nil
+}
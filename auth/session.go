@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// SessionTTL is how long a web UI session stays valid without being used.
+	SessionTTL time.Duration = 12 * time.Hour
+	// SessionRotate is how often an active session is given a new ID, limiting the use of a leaked cookie.
+	SessionRotate time.Duration = 15 * time.Minute
+	// SessionRotateGrace is how long the old ID still works after a rotation,
+	// so requests already sent with the old cookie are not logged out.
+	SessionRotateGrace time.Duration = 30 * time.Second
+)
+
+type Session struct {
+	ID       string
+	Identity Identity
+	Issued   time.Time
+	Expires  time.Time
+	// successor is the ID the session was rotated to; the old ID only lives out the grace period.
+	successor string
+}
+
+// SessionStore keeps the logged in web UI sessions in memory; they end when the server restarts.
+type SessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	rotate   time.Duration
+	grace    time.Duration
+	now      func() time.Time
+	sessions map[string]Session
+}
+
+// NewSessionStore creates a session store whose sessions expire after ttl of inactivity
+// and are given a new ID every rotate.
+func NewSessionStore(ttl time.Duration, rotate time.Duration) *SessionStore {
+	return &SessionStore{ttl: ttl, rotate: rotate, grace: SessionRotateGrace, now: time.Now, sessions: make(map[string]Session)}
+}
+
+// Create starts a new session for the identity.
+func (s *SessionStore) Create(identity Identity) Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	return s.issue(identity)
+}
+
+// Touch looks up a session and extends its expiry.
+// When the session is due for rotation it is replaced, and the returned session carries the new ID for the cookie.
+// The old ID keeps working for the grace period and is answered with the new session.
+func (s *SessionStore) Touch(id string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.touch(id)
+}
+
+// touch implements Touch; callers hold the lock.
+func (s *SessionStore) touch(id string) (Session, bool) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	now := s.now()
+	if !now.Before(session.Expires) {
+		delete(s.sessions, id)
+		return Session{}, false
+	}
+	if session.successor != "" {
+		return s.touch(session.successor)
+	}
+	if now.Sub(session.Issued) >= s.rotate {
+		rotated := s.issue(session.Identity)
+		session.successor = rotated.ID
+		session.Expires = now.Add(s.grace)
+		s.sessions[id] = session
+		return rotated, true
+	}
+	session.Expires = now.Add(s.ttl)
+	s.sessions[id] = session
+	return session, true
+}
+
+// Delete ends a session, along with the session an old ID was rotated to.
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id != "" {
+		session := s.sessions[id]
+		delete(s.sessions, id)
+		id = session.successor
+	}
+}
+
+// issue stores a session under a new random ID; callers hold the lock.
+func (s *SessionStore) issue(identity Identity) Session {
+	now := s.now()
+	session := Session{ID: randomHex(32), Identity: identity, Issued: now, Expires: now.Add(s.ttl)}
+	s.sessions[session.ID] = session
+	return session
+}
+
+// sweep drops expired sessions; callers hold the lock.
+func (s *SessionStore) sweep() {
+	now := s.now()
+	for id, session := range s.sessions {
+		if !now.Before(session.Expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestSessions returns a session store with a controllable clock.
+func newTestSessions(now *time.Time) *SessionStore {
+	sessions := NewSessionStore(time.Hour, 15*time.Minute)
+	sessions.now = func() time.Time { return *now }
+	return sessions
+}
+
+// TestAuth_SessionTouch tests that using a session extends its expiry.
+func TestAuth_SessionTouch(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	sessions := newTestSessions(&now)
+	session := sessions.Create(Identity{User: "alice"})
+
+	now = now.Add(10 * time.Minute)
+	touched, ok := sessions.Touch(session.ID)
+	if !ok || touched.ID != session.ID || touched.Identity.User != "alice" {
+		t.Fatalf("unexpected session: %+v %v", touched, ok)
+	}
+	if !touched.Expires.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected expiry to be extended, got %v", touched.Expires)
+	}
+}
+
+// TestAuth_SessionExpiry tests that idle sessions expire.
+func TestAuth_SessionExpiry(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	sessions := newTestSessions(&now)
+	session := sessions.Create(Identity{User: "alice"})
+
+	now = now.Add(time.Hour)
+	if _, ok := sessions.Touch(session.ID); ok {
+		t.Error("expected expired session to be rejected")
+	}
+}
+
+// TestAuth_SessionRotation tests that long-lived sessions are moved to a new ID.
+func TestAuth_SessionRotation(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	sessions := newTestSessions(&now)
+	session := sessions.Create(Identity{User: "alice"})
+
+	now = now.Add(20 * time.Minute)
+	rotated, ok := sessions.Touch(session.ID)
+	if !ok || rotated.ID == session.ID || rotated.Identity.User != "alice" {
+		t.Fatalf("expected rotated session, got %+v %v", rotated, ok)
+	}
+	if _, ok := sessions.Touch(rotated.ID); !ok {
+		t.Error("expected new session ID to be valid")
+	}
+	now = now.Add(SessionRotateGrace)
+	if _, ok := sessions.Touch(session.ID); ok {
+		t.Error("expected old session ID to be invalid after the grace period")
+	}
+	if _, ok := sessions.Touch(rotated.ID); !ok {
+		t.Error("expected new session ID to stay valid")
+	}
+}
+
+// TestAuth_SessionRotationGrace tests that a request sent with the old cookie just after a rotation is still let in.
+func TestAuth_SessionRotationGrace(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	sessions := newTestSessions(&now)
+	session := sessions.Create(Identity{User: "alice"})
+
+	now = now.Add(20 * time.Minute)
+	rotated, ok := sessions.Touch(session.ID)
+	if !ok || rotated.ID == session.ID {
+		t.Fatalf("expected rotated session, got %+v %v", rotated, ok)
+	}
+
+	now = now.Add(time.Second)
+	late, ok := sessions.Touch(session.ID)
+	if !ok || late.ID != rotated.ID || late.Identity.User != "alice" {
+		t.Fatalf("expected old cookie to get the rotated session, got %+v %v", late, ok)
+	}
+	if len(sessions.sessions) != 2 {
+		t.Errorf("expected the old ID not to be rotated again, got %d sessions", len(sessions.sessions))
+	}
+
+	sessions.Delete(session.ID)
+	if _, ok := sessions.Touch(rotated.ID); ok {
+		t.Error("expected logging out with the old cookie to end the rotated session")
+	}
+}
+
+// TestAuth_SessionDelete tests logging out.
+func TestAuth_SessionDelete(t *testing.T) {
+	sessions := NewSessionStore(time.Hour, time.Hour)
+	session := sessions.Create(Identity{User: "alice"})
+	sessions.Delete(session.ID)
+	if _, ok := sessions.Touch(session.ID); ok {
+		t.Error("expected deleted session to be invalid")
+	}
+}
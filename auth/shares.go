@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role is the access a user has to another user's list. Each role includes the ones before it.
+type Role string
+
+const (
+	// RoleViewer can see the items in the list.
+	RoleViewer Role = "viewer"
+	// RoleEditor can also create, update and delete items in the list.
+	RoleEditor Role = "editor"
+	// RoleAdmin can also share the list with other users.
+	RoleAdmin Role = "admin"
+)
+
+// roleRanks orders the roles from least to most access.
+var roleRanks = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+var (
+	ErrInvalidRole    = errors.New("role must be viewer, editor or admin")
+	ErrShareNotFound  = errors.New("share not found")
+	ErrShareWithOwner = errors.New("a list cannot be shared with its owner")
+)
+
+// Share grants a user a role on the list of items owned by List.
+type Share struct {
+	List    string    `json:"list"`
+	User    string    `json:"user"`
+	Role    Role      `json:"role"`
+	Created time.Time `json:"created"`
+}
+
+type ShareStore struct {
+	mu     sync.Mutex
+	file   jsonFile
+	shares []Share
+}
+
+// ParseRole returns the role with the given name.
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleRanks[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Allows reports whether the role grants at least the required role; the empty role allows nothing.
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
+
+// OpenShareStore loads the shares from the given json file, which is created on first write.
+func OpenShareStore(file string) (*ShareStore, error) {
+	store := &ShareStore{file: jsonFile{name: file}}
+	if err := store.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Set gives the user a role on the list, replacing any role they had.
+func (s *ShareStore) Set(list string, user string, role Role) (Share, error) {
+	if list == "" || user == "" || strings.ContainsFunc(user, isSpace) {
+		return Share{}, ErrInvalidUser
+	}
+	if list == user {
+		return Share{}, ErrShareWithOwner
+	}
+	if _, ok := roleRanks[role]; !ok {
+		return Share{}, ErrInvalidRole
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return Share{}, err
+	}
+
+	share := Share{List: list, User: user, Role: role, Created: time.Now().UTC()}
+	previous := slices.Clone(s.shares)
+	if index := s.index(list, user); index >= 0 {
+		s.shares[index] = share
+	} else {
+		s.shares = append(s.shares, share)
+	}
+	if err := s.file.save(s.shares); err != nil {
+		s.shares = previous
+		return Share{}, err
+	}
+	return share, nil
+}
+
+// Remove takes away the user's role on the list.
+func (s *ShareStore) Remove(list string, user string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return err
+	}
+
+	index := s.index(list, user)
+	if index < 0 {
+		return ErrShareNotFound
+	}
+	previous := slices.Clone(s.shares)
+	s.shares = slices.Delete(s.shares, index, index+1)
+	if err := s.file.save(s.shares); err != nil {
+		s.shares = previous
+		return err
+	}
+	return nil
+}
+
+// Shares returns who the list is shared with.
+func (s *ShareStore) Shares(list string) ([]Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	shares := []Share{}
+	for _, share := range s.shares {
+		if share.List == list {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+// Role returns the user's role on the list, or the empty role when it is not shared with them.
+// Errors reading the file deny access.
+func (s *ShareStore) Role(list string, user string) Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return ""
+	}
+	if index := s.index(list, user); index >= 0 {
+		return s.shares[index].Role
+	}
+	return ""
+}
+
+// index returns the position of the user's share on the list, or -1; callers hold the lock.
+func (s *ShareStore) index(list string, user string) int {
+	return slices.IndexFunc(s.shares, func(share Share) bool { return share.List == list && share.User == user })
+}
+
+// reloadIfChanged re-reads the share file when another process has modified it; callers hold the lock.
+func (s *ShareStore) reloadIfChanged() error {
+	shares, err := reload(&s.file, s.shares)
+	s.shares = shares
+	return err
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestAuth_ShareSetRemove tests giving, changing and removing a role on a list.
+func TestAuth_ShareSetRemove(t *testing.T) {
+	store, err := OpenShareStore(filepath.Join(t.TempDir(), "shares.json"))
+	if err != nil {
+		t.Fatalf("OpenShareStore failed: %v", err)
+	}
+
+	if _, err := store.Set("alice", "bob", RoleViewer); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := store.Set("alice", "bob", RoleEditor); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if role := store.Role("alice", "bob"); role != RoleEditor {
+		t.Errorf("Expected editor, got %q", role)
+	}
+	if shares, _ := store.Shares("alice"); len(shares) != 1 {
+		t.Errorf("Expected 1 share, got %d", len(shares))
+	}
+	if role := store.Role("bob", "alice"); role != "" {
+		t.Errorf("Expected no role on bob's list, got %q", role)
+	}
+
+	if err := store.Remove("alice", "bob"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if role := store.Role("alice", "bob"); role != "" {
+		t.Errorf("Expected no role after remove, got %q", role)
+	}
+	if err := store.Remove("alice", "bob"); err != ErrShareNotFound {
+		t.Errorf("Expected ErrShareNotFound, got %v", err)
+	}
+}
+
+// TestAuth_ShareInvalid tests the rejected shares.
+func TestAuth_ShareInvalid(t *testing.T) {
+	store, _ := OpenShareStore(filepath.Join(t.TempDir(), "shares.json"))
+	if _, err := store.Set("alice", "alice", RoleViewer); err != ErrShareWithOwner {
+		t.Errorf("Expected ErrShareWithOwner, got %v", err)
+	}
+	if _, err := store.Set("alice", "bob", Role("owner")); err != ErrInvalidRole {
+		t.Errorf("Expected ErrInvalidRole, got %v", err)
+	}
+	if _, err := store.Set("", "bob", RoleViewer); err != ErrInvalidUser {
+		t.Errorf("Expected ErrInvalidUser, got %v", err)
+	}
+}
+
+// TestAuth_RoleAllows tests the role hierarchy.
+func TestAuth_RoleAllows(t *testing.T) {
+	if !RoleAdmin.Allows(RoleEditor) || !RoleEditor.Allows(RoleViewer) || !RoleViewer.Allows(RoleViewer) {
+		t.Error("Expected higher roles to include lower ones")
+	}
+	if RoleViewer.Allows(RoleEditor) || RoleEditor.Allows(RoleAdmin) || Role("").Allows(RoleViewer) {
+		t.Error("Expected lower roles not to include higher ones")
+	}
+	if role, err := ParseRole(" Editor "); err != nil || role != RoleEditor {
+		t.Errorf("Expected editor, got %q %v", role, err)
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MinPasswordLength is the shortest password accepted for a new user.
+const MinPasswordLength int = 8
+
+// passwordIterations is the PBKDF2-SHA256 work factor for new hashes, following the OWASP recommendation.
+// Stored hashes record their own iteration count, so raising it does not invalidate existing passwords.
+var passwordIterations = 600000
+
+var (
+	ErrUserExists       = errors.New("user already exists")
+	ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+)
+
+type User struct {
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"password_hash"`
+	Scopes       []string  `json:"scopes,omitempty"`
+	Created      time.Time `json:"created"`
+}
+
+type UserStore struct {
+	mu    sync.Mutex
+	file  jsonFile
+	users []User
+}
+
+// dummyHash is verified against when the user does not exist, so unknown and known users take equally long.
+var dummyHash = sync.OnceValue(func() string { return hashPassword("not a real password") })
+
+// OpenUserStore loads the users from the given json file, which is created on first write.
+func OpenUserStore(file string) (*UserStore, error) {
+	store := &UserStore{file: jsonFile{name: file}}
+	if err := store.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Create adds a user who can log in to the web UI with the given password.
+func (s *UserStore) Create(name string, password string, scopes []string) (User, error) {
+	if name == "" || strings.ContainsFunc(name, isSpace) {
+		return User{}, ErrInvalidUser
+	}
+	if len(password) < MinPasswordLength {
+		return User{}, ErrPasswordTooShort
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reloadIfChanged(); err != nil {
+		return User{}, err
+	}
+	if slices.ContainsFunc(s.users, func(user User) bool { return user.Name == name }) {
+		return User{}, ErrUserExists
+	}
+
+	user := User{Name: name, PasswordHash: hashPassword(password), Scopes: scopes, Created: time.Now().UTC()}
+	s.users = append(s.users, user)
+	if err := s.file.save(s.users); err != nil {
+		s.users = s.users[:len(s.users)-1]
+		return User{}, err
+	}
+	return user, nil
+}
+
+// Verify checks a user name and password and returns the user's identity.
+func (s *UserStore) Verify(name string, password string) (Identity, bool) {
+	s.mu.Lock()
+	if err := s.reloadIfChanged(); err != nil {
+		s.mu.Unlock()
+		return Identity{}, false
+	}
+	index := slices.IndexFunc(s.users, func(user User) bool { return user.Name == name })
+	var user User
+	if index >= 0 {
+		user = s.users[index]
+	}
+	s.mu.Unlock()
+
+	// hashing is slow, so it runs outside the lock
+	if index < 0 {
+		checkPassword(dummyHash(), password)
+		return Identity{}, false
+	}
+	if !checkPassword(user.PasswordHash, password) {
+		return Identity{}, false
+	}
+	return Identity{User: user.Name, Scopes: user.Scopes}, true
+}
+
+// reloadIfChanged re-reads the user file when another process has modified it; callers hold the lock.
+func (s *UserStore) reloadIfChanged() error {
+	users, err := reload(&s.file, s.users)
+	s.users = users
+	return err
+}
+
+// hashPassword derives a salted PBKDF2-SHA256 hash in the form pbkdf2-sha256$<iterations>$<salt>$<hash>.
+func hashPassword(password string) string {
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, 32)
+	if err != nil {
+		panic(err)
+	}
+	return "pbkdf2-sha256$" + strconv.Itoa(passwordIterations) + "$" +
+		base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+}
+
+// checkPassword reports whether the password matches a hash produced by hashPassword.
+func checkPassword(hash string, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// init keeps password hashing fast in tests.
+func init() {
+	passwordIterations = 1000
+}
+
+// TestAuth_UserVerify tests that only the correct password logs a user in.
+func TestAuth_UserVerify(t *testing.T) {
+	store, err := OpenUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatalf("OpenUserStore failed: %v", err)
+	}
+	if _, err := store.Create("alice", "correct horse", []string{ScopeAdmin}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	identity, ok := store.Verify("alice", "correct horse")
+	if !ok || identity.User != "alice" || !identity.IsAdmin() {
+		t.Errorf("unexpected identity: %+v %v", identity, ok)
+	}
+	if _, ok := store.Verify("alice", "wrong password"); ok {
+		t.Error("expected wrong password to fail")
+	}
+	if _, ok := store.Verify("bob", "correct horse"); ok {
+		t.Error("expected unknown user to fail")
+	}
+}
+
+// TestAuth_UserCreateErrors tests duplicate users and short passwords.
+func TestAuth_UserCreateErrors(t *testing.T) {
+	store, _ := OpenUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if _, err := store.Create("alice", "short", nil); err != ErrPasswordTooShort {
+		t.Errorf("expected ErrPasswordTooShort, got %v", err)
+	}
+	if _, err := store.Create("alice", "long enough", nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := store.Create("alice", "long enough", nil); err != ErrUserExists {
+		t.Errorf("expected ErrUserExists, got %v", err)
+	}
+	if _, err := store.Create("", "long enough", nil); err != ErrInvalidUser {
+		t.Errorf("expected ErrInvalidUser, got %v", err)
+	}
+}
+
+// TestAuth_PasswordHashStored tests that passwords are stored as salted PBKDF2 hashes.
+func TestAuth_PasswordHashStored(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.json")
+	store, _ := OpenUserStore(file)
+	first, _ := store.Create("alice", "same password", nil)
+	second, _ := store.Create("bob", "same password", nil)
+
+	if !strings.HasPrefix(first.PasswordHash, "pbkdf2-sha256$1000$") {
+		t.Errorf("unexpected hash format: %s", first.PasswordHash)
+	}
+	if first.PasswordHash == second.PasswordHash {
+		t.Error("expected different salts for the same password")
+	}
+	data, _ := os.ReadFile(file)
+	if strings.Contains(string(data), "same password") {
+		t.Errorf("user file leaks the password: %s", data)
+	}
+}
+
+// TestAuth_CheckPasswordIterations tests that hashes keep verifying after the work factor changes.
+func TestAuth_CheckPasswordIterations(t *testing.T) {
+	hash := hashPassword("old password")
+	passwordIterations = 2000
+	defer func() { passwordIterations = 1000 }()
+
+	if !checkPassword(hash, "old password") {
+		t.Error("expected hash with old iteration count to verify")
+	}
+	if checkPassword("md5$abc", "old password") {
+		t.Error("expected unknown hash format to fail")
+	}
+}
@@ -0,0 +1,411 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/actor"
+	"todo-app/auth"
+	"todo-app/logging"
+	"todo-app/storage"
+)
+
+const (
+	// DefaultMaxRetries is how many times a request is retried after a retryable failure.
+	DefaultMaxRetries = 3
+
+	// DefaultRetryDelay is the wait before the first retry; it doubles for every further retry.
+	DefaultRetryDelay = 200 * time.Millisecond
+
+	// maxErrorBody bounds how much of an error response is read into the error message.
+	maxErrorBody = 4096
+)
+
+// ErrUnauthorized is returned when the server rejects the token.
+var ErrUnauthorized = errors.New("unauthorized: missing or invalid token")
+
+// sentinels are the errors the server reports in its response bodies, matched by message
+// so callers can use errors.Is exactly as they do against the local actor.
+var sentinels = []error{
+	storage.ErrInvalidID,
+	storage.ErrEmptyDescription,
+	storage.ErrLongDescription,
+	storage.ErrInvalidStatus,
+	storage.ErrItemNotFound,
+	storage.ErrItemExists,
+	storage.ErrNoItems,
+	actor.ErrForbidden,
+	actor.ErrListNotFound,
+	auth.ErrInvalidUser,
+	auth.ErrInvalidRole,
+	auth.ErrShareNotFound,
+	auth.ErrShareWithOwner,
+}
+
+// Client calls the HTTP API of a running server.
+// It has the same method set as handler.ActorInterface, so code written against the local actor works remotely;
+// commands run as the user the token belongs to.
+type Client struct {
+	baseURL *url.URL
+	token   string
+
+	// HTTPClient sends the requests.
+	HTTPClient *http.Client
+	// MaxRetries is how many times a request is retried after a rate limit, a timeout reported by the server,
+	// or, for requests that are safe to repeat, a connection error.
+	MaxRetries int
+	// RetryDelay is the wait before the first retry unless the server sends Retry-After.
+	RetryDelay time.Duration
+}
+
+// Error is an error response from the server.
+// It wraps the matching sentinel error, such as storage.ErrItemNotFound, when the server reported one.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	err        error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// errorBody is the JSON error sent for denied, rate limited and timed out requests.
+type errorBody struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// healthReport is the JSON body of /healthz and /readyz.
+type healthReport struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Time   string `json:"time"`
+	} `json:"checks"`
+}
+
+// New creates a client for the server at baseURL, such as http://localhost:8080, authenticating with the API token.
+func New(baseURL string, token string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q: must be http:// or https:// with a host", baseURL)
+	}
+	return &Client{
+		baseURL:    u,
+		token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: DefaultMaxRetries,
+		RetryDelay: DefaultRetryDelay,
+	}, nil
+}
+
+// Create creates a new item with the given description and status.
+func (c *Client) Create(ctx context.Context, description string, status string) (storage.Item, error) {
+	return c.CreateIn(ctx, "", description, status)
+}
+
+// CreateIn creates a new item in another user's list, which needs the editor role on it.
+// An empty list creates the item in the caller's own list.
+func (c *Client) CreateIn(ctx context.Context, list string, description string, status string) (storage.Item, error) {
+	var item storage.Item
+	err := c.do(ctx, http.MethodPost, "/create", storage.Item{Description: description, Status: status, Owner: list}, &item)
+	return item, err
+}
+
+// Update updates an existing item with the given ID, description, and status.
+func (c *Client) Update(ctx context.Context, id int, description string, status string) (storage.Item, error) {
+	var item storage.Item
+	err := c.do(ctx, http.MethodPut, "/update", storage.Item{ID: id, Description: description, Status: status}, &item)
+	return item, err
+}
+
+// Delete deletes the item with the given ID.
+func (c *Client) Delete(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/delete/"+strconv.Itoa(id), nil, nil)
+}
+
+// Restore puts a deleted item back with its ID, list and creation time, which needs the editor role on the list.
+func (c *Client) Restore(ctx context.Context, item storage.Item) (storage.Item, error) {
+	var restored storage.Item
+	err := c.do(ctx, http.MethodPost, "/restore", item, &restored)
+	return restored, err
+}
+
+// ListAll returns all items visible to the caller.
+func (c *Client) ListAll(ctx context.Context) (storage.Items, error) {
+	var list []storage.Item
+	if err := c.do(ctx, http.MethodGet, "/get", nil, &list); err != nil {
+		return storage.Items{}, err
+	}
+	items := make(storage.Items, len(list))
+	for _, item := range list {
+		items[item.ID] = item
+	}
+	return items, nil
+}
+
+// List returns the item with the given ID.
+func (c *Client) List(ctx context.Context, id int) (storage.Item, error) {
+	var item storage.Item
+	err := c.do(ctx, http.MethodGet, "/get/"+strconv.Itoa(id), nil, &item)
+	return item, err
+}
+
+// Shares returns who the list is shared with; an empty list is the caller's own.
+func (c *Client) Shares(ctx context.Context, list string) ([]auth.Share, error) {
+	var shares []auth.Share
+	err := c.do(ctx, http.MethodGet, "/shares"+listQuery(list), nil, &shares)
+	return shares, err
+}
+
+// Share gives a user a role on the list; an empty list is the caller's own.
+func (c *Client) Share(ctx context.Context, list string, user string, role auth.Role) (auth.Share, error) {
+	var share auth.Share
+	request := map[string]string{"list": list, "user": user, "role": string(role)}
+	err := c.do(ctx, http.MethodPost, "/shares", request, &share)
+	return share, err
+}
+
+// Unshare takes a user's role on the list away; an empty list is the caller's own.
+func (c *Client) Unshare(ctx context.Context, list string, user string) error {
+	return c.do(ctx, http.MethodDelete, "/shares/"+url.PathEscape(user)+listQuery(list), nil, nil)
+}
+
+// Ping returns once the server's actor has answered its liveness probe.
+func (c *Client) Ping(ctx context.Context) error {
+	report, err := c.probe(ctx, "/healthz")
+	if err != nil {
+		return err
+	}
+	if check := report.Checks["actor"]; check.Status != "ok" {
+		return fmt.Errorf("actor check failed: %s", check.Error)
+	}
+	return nil
+}
+
+// CheckStorage reports the state of the server's data file from its readiness probe.
+// The error is set when the server could not run the check in time.
+func (c *Client) CheckStorage(ctx context.Context) (storage.Health, error) {
+	report, err := c.probe(ctx, "/readyz")
+	if err != nil {
+		return storage.Health{}, err
+	}
+	if check := report.Checks["storage_lock"]; check.Status != "ok" {
+		return storage.Health{}, fmt.Errorf("storage check failed: %s", check.Error)
+	}
+	var health storage.Health
+	if check := report.Checks["data_file"]; check.Status != "ok" {
+		health.File = matchSentinel(check.Error)
+		if health.File == nil {
+			health.File = errors.New(check.Error)
+		}
+	}
+	lastSave := report.Checks["last_save"]
+	if lastSave.Time != "" {
+		health.LastSave, _ = time.Parse(time.RFC3339, lastSave.Time)
+	}
+	if lastSave.Status != "ok" {
+		health.LastSaveErr = errors.New(lastSave.Error)
+	}
+	return health, nil
+}
+
+// probe calls a health endpoint; a failing check is reported in the body with 503, not as an error.
+func (c *Client) probe(ctx context.Context, path string) (healthReport, error) {
+	var report healthReport
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return report, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return report, decodeError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return report, fmt.Errorf("decode %s response: %w", path, err)
+	}
+	return report, nil
+}
+
+// do sends a JSON request and decodes a successful JSON response into out, when given.
+func (c *Client) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body []byte
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// send performs the request, retrying rate limited requests, requests the server timed out before processing,
+// and connection errors for requests that are safe to repeat.
+func (c *Client) send(ctx context.Context, method string, path string, body []byte) (*http.Response, error) {
+	delay := c.RetryDelay
+	for attempt := 0; ; attempt++ {
+		req, err := c.newRequest(ctx, method, path, body)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.HTTPClient.Do(req)
+		wait := delay
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !idempotent(method) || !temporary(err) || attempt >= c.MaxRetries {
+				return nil, err
+			}
+		case resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusServiceUnavailable && !isProbe(path)):
+			if attempt >= c.MaxRetries {
+				return resp, nil
+			}
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+			// drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// newRequest builds a request with the token and the caller's trace ID.
+func (c *Client) newRequest(ctx context.Context, method string, path string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String()+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if traceID, ok := logging.TraceID(ctx); ok {
+		req.Header.Set("X-Request-ID", traceID)
+	}
+	return req, nil
+}
+
+// decodeError turns an error response into an *Error wrapping the sentinel error it reports.
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	e := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+
+	var body errorBody
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") && json.Unmarshal(data, &body) == nil && body.Error != "" {
+		e.Code, e.Message = body.Code, body.Error
+	}
+	if e.Message == "" {
+		e.Message = resp.Status
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		e.err = ErrUnauthorized
+	case e.Code == "timeout":
+		e.err = context.DeadlineExceeded
+	case e.Code == "shutting_down":
+		e.err = actor.ErrStopped
+	default:
+		e.err = matchSentinel(e.Message)
+	}
+	return e
+}
+
+// matchSentinel returns the sentinel error a server message starts with, or nil.
+func matchSentinel(message string) error {
+	for _, sentinel := range sentinels {
+		if strings.HasPrefix(message, sentinel.Error()) {
+			return sentinel
+		}
+	}
+	for _, sentinel := range []error{storage.ErrDataFileNotOpen, storage.ErrDataFileNotReadable, storage.ErrDataFileNotWritable} {
+		if message == sentinel.Error() {
+			return sentinel
+		}
+	}
+	return nil
+}
+
+// listQuery returns the query string selecting another user's list, or nothing for the caller's own.
+func listQuery(list string) string {
+	if list == "" {
+		return ""
+	}
+	return "?" + url.Values{"list": {list}}.Encode()
+}
+
+// idempotent reports whether a request can be sent again after a connection error without applying it twice.
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+}
+
+// temporary reports whether a transport error is worth retrying.
+func temporary(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) || isConnRefused(err)
+}
+
+// isConnRefused reports whether the server was not accepting connections, for example while restarting.
+func isConnRefused(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isProbe reports whether the path is a health probe, which reports failed checks with 503.
+func isProbe(path string) bool {
+	return path == "/healthz" || path == "/readyz"
+}
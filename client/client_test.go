@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+	"todo-app/actor"
+	"todo-app/auth"
+	"todo-app/handler"
+	"todo-app/storage"
+)
+
+// the client must be usable wherever the handlers use the local actor
+var _ handler.ActorInterface = (*Client)(nil)
+
+// setupServer starts the real API on an empty data file and returns a client with an admin token.
+func setupServer(t *testing.T) *Client {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := storage.Open(ctx, filepath.Join(dir, "todos.json")); err != nil {
+		t.Fatalf("Open storage failed: %v", err)
+	}
+	tokens, err := auth.OpenTokenStore(filepath.Join(dir, "tokens.json"))
+	if err != nil {
+		t.Fatalf("OpenTokenStore failed: %v", err)
+	}
+	secret, _, err := tokens.Create("root", []string{auth.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("Create token failed: %v", err)
+	}
+	handler.InitAuth(tokens, nil)
+	handler.InitActor(ctx, nil)
+
+	mux := http.NewServeMux()
+	handler.AddRoutes(mux)
+	server := httptest.NewServer(handler.Wrap(mux))
+	t.Cleanup(server.Close)
+
+	c, err := New(server.URL, secret)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return c
+}
+
+// fakeServer returns a client for a server answering every request with fn.
+func fakeServer(t *testing.T, fn http.HandlerFunc) *Client {
+	server := httptest.NewServer(fn)
+	t.Cleanup(server.Close)
+	c, err := New(server.URL, "token")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	c.RetryDelay = time.Millisecond
+	return c
+}
+
+// TestClient_New tests that only absolute http and https URLs are accepted.
+func TestClient_New(t *testing.T) {
+	for _, raw := range []string{"localhost:8080", "ftp://host", "http://", "://bad"} {
+		if _, err := New(raw, ""); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+	if _, err := New("https://todo.example.com/", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestClient_CRUD tests every item operation against the real server.
+func TestClient_CRUD(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	item, err := c.Create(ctx, "Remote item", "")
+	if err != nil || item.ID == 0 || item.Status != "not_started" {
+		t.Fatalf("Create failed: %+v, %v", item, err)
+	}
+	if _, err := c.Create(ctx, "Another item", "is_finished"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	updated, err := c.Update(ctx, item.ID, "Remote item", "in_progress")
+	if err != nil || updated.Status != "in_progress" {
+		t.Fatalf("Update failed: %+v, %v", updated, err)
+	}
+	got, err := c.List(ctx, item.ID)
+	if err != nil || got.Description != "Remote item" {
+		t.Fatalf("List failed: %+v, %v", got, err)
+	}
+	items, err := c.ListAll(ctx)
+	if err != nil || len(items) != 2 || items[item.ID].Status != "in_progress" {
+		t.Fatalf("ListAll failed: %+v, %v", items, err)
+	}
+	if err := c.Delete(ctx, item.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := c.List(ctx, item.ID); !errors.Is(err, storage.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound after delete, got %v", err)
+	}
+	restored, err := c.Restore(ctx, updated)
+	if err != nil || restored.ID != item.ID || !restored.Created.Equal(item.Created) || restored.Owner != item.Owner {
+		t.Fatalf("Restore failed: %+v, %v", restored, err)
+	}
+	if _, err := c.Restore(ctx, updated); !errors.Is(err, storage.ErrItemExists) {
+		t.Errorf("expected ErrItemExists restoring twice, got %v", err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+	if health, err := c.CheckStorage(ctx); err != nil || health.File != nil {
+		t.Errorf("CheckStorage failed: %+v, %v", health, err)
+	}
+}
+
+// TestClient_SentinelErrors tests that server errors decode into the errors the local actor returns.
+func TestClient_SentinelErrors(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	if _, err := c.Create(ctx, "", ""); !errors.Is(err, storage.ErrEmptyDescription) {
+		t.Errorf("expected ErrEmptyDescription, got %v", err)
+	}
+	if _, err := c.Create(ctx, "Item", "done"); !errors.Is(err, storage.ErrInvalidStatus) {
+		t.Errorf("expected ErrInvalidStatus, got %v", err)
+	}
+	var apiErr *Error
+	if _, err := c.Update(ctx, 999, "Item", "in_progress"); !errors.Is(err, storage.ErrItemNotFound) || !errors.As(err, &apiErr) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+
+	c.token = "wrong"
+	if _, err := c.ListAll(ctx); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+// TestClient_JSONErrors tests decoding of the JSON error codes.
+func TestClient_JSONErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"forbidden", actor.ErrForbidden},
+		{"timeout", context.DeadlineExceeded},
+		{"shutting_down", actor.ErrStopped},
+	}
+	for _, tt := range tests {
+		c := fakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if tt.code == "forbidden" {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":"permission denied: editor role required","code":"forbidden"}`))
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"unavailable","code":"` + tt.code + `"}`))
+		})
+		c.MaxRetries = 0
+		_, err := c.List(context.Background(), 1)
+		var apiErr *Error
+		if !errors.Is(err, tt.want) || !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+			t.Errorf("%s: expected %v, got %v", tt.code, tt.want, err)
+		}
+	}
+}
+
+// TestClient_Retries tests that rate limited and timed out requests are retried.
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	c := fakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("missing bearer token")
+		}
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`[{"id":1,"description":"Item","status":"not_started"}]`))
+		}
+	})
+	items, err := c.ListAll(context.Background())
+	if err != nil || len(items) != 1 || calls.Load() != 3 {
+		t.Fatalf("expected success on the third attempt, got %v, %v after %d calls", items, err, calls.Load())
+	}
+
+	calls.Store(0)
+	c = fakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.MaxRetries = 2
+	if _, err := c.ListAll(context.Background()); err == nil || calls.Load() != 3 {
+		t.Errorf("expected an error after 3 attempts, got %v after %d calls", err, calls.Load())
+	}
+}
+
+// TestClient_ContextCancelled tests that waiting for a retry stops when the context ends.
+func TestClient_ContextCancelled(t *testing.T) {
+	c := fakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if _, err := c.List(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if time.Since(started) > 5*time.Second {
+		t.Error("expected the retry wait to stop with the context")
+	}
+}
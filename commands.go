@@ -0,0 +1,632 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"todo-app/auth"
+	"todo-app/config"
+	"todo-app/handler"
+	"todo-app/logging"
+	"todo-app/storage"
+	"todo-app/terminal"
+)
+
+// programName is how the usage text refers to the binary.
+const programName string = "todo"
+
+// exit codes of the CLI
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+// cli is what every command needs: the application context, the settings, the data folder and where output goes.
+type cli struct {
+	ctx      context.Context
+	settings *config.Settings
+	dir      string
+	stdout   io.Writer
+	stderr   io.Writer
+	closers  []func()
+}
+
+// command is a subcommand with its own flags and positional arguments.
+type command struct {
+	name    string
+	args    string
+	summary string
+	// hidden commands are left out of the usage text and completions
+	hidden bool
+	// setup defines the command's flags and returns the function that runs it with the positional arguments
+	setup func(fs *flag.FlagSet) func(c *cli, args []string) error
+}
+
+// usageError reports wrong positional arguments; the command's usage is printed after it.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// reportedError is a failure already explained on stderr, so run only sets the exit code.
+type reportedError struct {
+	error
+}
+
+// commands returns the subcommands in the order the usage text lists them.
+func commands() []command {
+	return []command{
+		addCommand(),
+		listCommand(),
+		getCommand(),
+		doneCommand(),
+		editCommand(),
+		rmCommand(),
+		tuiCommand(),
+		shellCommand(),
+		serveCommand(),
+		tokenCommand(),
+		userCommand(),
+		shareCommand(),
+		unshareCommand(),
+		sharesCommand(),
+		printConfigCommand(),
+		completionCommand(),
+		completeCommand(),
+	}
+}
+
+// findCommand looks up a subcommand by name.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// run parses the command line, runs the subcommand and returns the process exit code:
+// 0 on success, 1 when the command failed and 2 for usage errors.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
+	}
+	name, args := args[0], args[1:]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		return runHelp(args, stdout, stderr)
+	}
+	switch name {
+	case "-print-config", "--print-config":
+		name = "print-config"
+	case "-tui", "--tui":
+		name = "tui"
+	case "-shell", "--shell":
+		name = "shell"
+	case "-completion", "--completion":
+		name = "completion"
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(stderr, "Unknown command %q\n\n", name)
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	fs := newFlagSet(cmd, stderr)
+	runCmd := cmd.setup(fs)
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		// the flag package has printed the error and usage
+		return exitUsage
+	}
+
+	settings, err := loadSettings(fs.Lookup("config").Value.String())
+	if err != nil {
+		fmt.Fprintf(stderr, "Config failed to load: %v\n", err)
+		return exitFailed
+	}
+	c, err := newCLI(settings, stdout, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Cannot establish working data folder: %v\n", err)
+		return exitFailed
+	}
+	defer c.close()
+
+	err = runCmd(c, positional)
+	var usageErr usageError
+	var reported reportedError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%s\n\n", err)
+		fs.Usage()
+		return exitUsage
+	case errors.As(err, &reported):
+		return exitFailed
+	default:
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		slog.ErrorContext(c.ctx, "Command failed", "command", cmd.name, "error", err)
+		return exitFailed
+	}
+}
+
+// runHelp prints the overview, or the flags of one command.
+func runHelp(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stdout)
+		return exitOK
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(stderr, "Unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+	fs := newFlagSet(cmd, stdout)
+	cmd.setup(fs)
+	fs.Usage()
+	return exitOK
+}
+
+// newFlagSet creates the flag set of a command, printing its usage line, summary and flags on -h.
+// Every command takes -config.
+func newFlagSet(cmd command, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.String("config", "", "read settings from this .json or .toml file (default $"+configEnv+", or config.toml or config.json in the user config folder)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s", programName, cmd.name)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprint(fs.Output(), " [flags]")
+		}
+		if cmd.args != "" {
+			fmt.Fprintf(fs.Output(), " %s", cmd.args)
+		}
+		fmt.Fprintf(fs.Output(), "\n\n%s\n", cmd.summary)
+		if hasFlags {
+			fmt.Fprint(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// printUsage prints the list of commands.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, `Todo-App
+Manage to-do items from the command line, or serve them over HTTP.
+
+Usage:
+  %s <command> [flags] [arguments]
+
+Commands:
+`, programName)
+	for _, cmd := range commands() {
+		if cmd.hidden {
+			continue
+		}
+		summary, _, _ := strings.Cut(cmd.summary, "\n")
+		fmt.Fprintf(w, "  %-13s %s\n", cmd.name, summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s help <command>\" for the flags and arguments of a command.\n", programName)
+}
+
+// parseArgs parses flags given before, between or after the positional arguments, which it returns.
+// Everything after "--" is positional.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if i := len(args) - len(rest); i > 0 && args[i-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// loadSettings layers the config file and TODO_* environment variables over the defaults.
+// The file named by -config or $TODO_CONFIG must exist, the default ones are read when present.
+func loadSettings(file string) (*config.Settings, error) {
+	if file == "" {
+		file = os.Getenv(configEnv)
+	}
+	if file != "" {
+		return config.Resolve(file, true, os.Environ())
+	}
+	return config.Resolve(defaultConfigFile(), false, os.Environ())
+}
+
+// defaultConfigFile returns the first config file found in the user config folder, then the
+// config.json the server used to read from the data folder, or "" when there is none.
+func defaultConfigFile() string {
+	var candidates []string
+	if configDir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, configDir+"\\"+configfolder+"\\config.toml", configDir+"\\"+configfolder+"\\"+configfile)
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		candidates = append(candidates, cacheDir+"\\"+config.Default().Data.Folder+"\\"+configfile)
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// newCLI sets up the trace ID, data folder and file logger shared by every command.
+func newCLI(settings *config.Settings, stdout io.Writer, stderr io.Writer) (*cli, error) {
+	// setup application context with trace id
+	traceID := logging.GenerateID()
+	c := &cli{ctx: logging.WithTraceID(context.Background(), traceID), settings: settings, stdout: stdout, stderr: stderr}
+
+	// resolve the appdata data sub folder
+	dir, err := logging.CreateAppDataFolder(settings.Data.Folder)
+	if err != nil {
+		return nil, err
+	}
+	c.dir = dir
+
+	// wire up logger
+	logOptions, err := logging.LoggerOptions(settings.Log.Level, settings.Log.Source)
+	if err != nil {
+		return nil, err
+	}
+	if logFileHandle, err := logging.OpenLogFile(c.path(settings.Data.LogFile)); err == nil {
+		c.closers = append(c.closers, func() { logFileHandle.Close() })
+		logHandler, err := logging.NewHandler(logFileHandle, settings.Log.Format, logOptions)
+		if err != nil {
+			return nil, err
+		}
+		slog.SetDefault(slog.New(&ContextHandler{logHandler}))
+		slog.InfoContext(c.ctx, "Starting up logging", "config", settings.File)
+	}
+	return c, nil
+}
+
+// path returns the path of a file in the data folder.
+func (c *cli) path(file string) string {
+	return c.dir + "\\" + file
+}
+
+// close releases what the command opened, in reverse order.
+func (c *cli) close() {
+	for _, closer := range slices.Backward(c.closers) {
+		closer()
+	}
+}
+
+// serverFlags maps the serve flags that override settings to their keys.
+var serverFlags = map[string]string{
+	"addr":            "server.addr",
+	"tls-cert":        "server.tls_cert",
+	"tls-key":         "server.tls_key",
+	"tls-self-signed": "server.tls_self_signed",
+}
+
+// serveCommand starts the HTTP server.
+func serveCommand() command {
+	return command{name: "serve", summary: "Start the HTTP API server and web UI", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		assetsDir := fs.String("assets-dir", "", "serve templates and static files from this folder instead of the embedded copies (reloaded on every request)")
+		fs.String("addr", "", "listen address, host:port or unix:/path/to.sock (default \":8080\")")
+		fs.String("tls-cert", "", "with -tls-key, serve HTTPS with this certificate file")
+		fs.String("tls-key", "", "with -tls-cert, serve HTTPS with this key file")
+		fs.Bool("tls-self-signed", false, "serve HTTPS with a self-signed certificate for localhost, generated in the data folder")
+		rateLimit := fs.Float64("rate-limit", handler.DefaultLimits.RequestsPerSecond, "requests per second allowed per client IP and per user (0 disables rate limiting)")
+		rateBurst := fs.Int("rate-burst", handler.DefaultLimits.Burst, "requests a client may make at once before being rate limited")
+		maxBody := fs.Int64("max-body-bytes", handler.DefaultLimits.MaxBodyBytes, "largest request body accepted")
+		maxDescription := maxDescriptionFlag(fs)
+
+		return func(c *cli, args []string) error {
+			if len(args) > 0 {
+				return usageError("serve takes no arguments")
+			}
+			storage.SetMaxDescriptionLength(*maxDescription)
+			runMode = RunModeServer
+
+			// flags given on the command line override the config file and environment
+			var err error
+			fs.Visit(func(f *flag.Flag) {
+				if key, ok := serverFlags[f.Name]; ok {
+					err = errors.Join(err, c.settings.Set(key, f.Value.String(), "flag -"+f.Name))
+				}
+			})
+			cfg := c.settings.Config
+			if err == nil {
+				err = cfg.Server.Validate()
+			}
+			if err != nil {
+				return fmt.Errorf("invalid server settings: %w", err)
+			}
+
+			// the server owns the data file while it runs
+			storagefile := c.path(cfg.Data.ItemsFile)
+			if err := storage.Open(c.ctx, storagefile); err != nil {
+				return fmt.Errorf("open data file %s: %w", storagefile, err)
+			}
+
+			// start server mode
+			slog.InfoContext(c.ctx, "Starting server mode", "addr", cfg.Server.Addr, "tls", cfg.Server.TLS())
+			limits := handler.Limits{RequestsPerSecond: *rateLimit, Burst: *rateBurst, MaxBodyBytes: *maxBody}
+			if err := startServer(c.ctx, c.dir, *assetsDir, limits, cfg.Data, cfg.Server); err != nil {
+				return reportedError{err}
+			}
+			return nil
+		}
+	}}
+}
+
+// printConfigCommand shows the effective settings.
+func printConfigCommand() command {
+	return command{name: "print-config",
+		summary: "Print the effective settings and where each comes from\nSettings are layered: defaults, then the config file, then TODO_* environment variables, then flags.\nThe output is in TOML and can be saved as a config.toml to start from.",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			return func(c *cli, args []string) error {
+				if len(args) > 0 {
+					return usageError("print-config takes no arguments")
+				}
+				return c.settings.Print(c.stdout)
+			}
+		}}
+}
+
+// tokenCommand manages the API tokens.
+func tokenCommand() command {
+	return command{name: "token", args: "create <user> | list | revoke <token id>",
+		summary: "Manage API tokens\nThe secret of a new token is printed once; only its hash is stored.",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			scope := scopeFlag(fs)
+			return func(c *cli, args []string) error {
+				action, args := firstArg(args)
+				switch {
+				case action == "create" && len(args) == 1:
+					return manageTokens(c.path(c.settings.Data.TokensFile), func(store *auth.TokenStore) error {
+						secret, token, err := store.Create(args[0], parseScopes(*scope))
+						if err != nil {
+							return err
+						}
+						fmt.Fprintf(c.stdout, "Created token %s for %s\n%s\n(store it now, it cannot be shown again)\n", token.ID, token.User, secret)
+						slog.InfoContext(c.ctx, "Created API token", "TokenID", token.ID, "User", token.User, "Scopes", token.Scopes)
+						return nil
+					})
+				case action == "list" && len(args) == 0:
+					return manageTokens(c.path(c.settings.Data.TokensFile), func(store *auth.TokenStore) error {
+						tokens, err := store.List()
+						if err != nil {
+							return err
+						}
+						for _, token := range tokens {
+							fmt.Fprintf(c.stdout, "%s  %-16s  %-12s  %s\n", token.ID, token.User, strings.Join(token.Scopes, ","), token.Created.Format("2006-01-02 15:04"))
+						}
+						return nil
+					})
+				case action == "revoke" && len(args) == 1:
+					return manageTokens(c.path(c.settings.Data.TokensFile), func(store *auth.TokenStore) error {
+						if err := store.Revoke(args[0]); err != nil {
+							return err
+						}
+						fmt.Fprintf(c.stdout, "Revoked token %s\n", args[0])
+						slog.InfoContext(c.ctx, "Revoked API token", "TokenID", args[0])
+						return nil
+					})
+				}
+				return usageError("token needs create <user>, list or revoke <token id>")
+			}
+		}}
+}
+
+// userCommand manages the web UI logins.
+func userCommand() command {
+	return command{name: "user", args: "add <user>",
+		summary: "Create a web UI login\nThe password is read from stdin.",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			scope := scopeFlag(fs)
+			return func(c *cli, args []string) error {
+				action, names := firstArg(args)
+				if action != "add" || len(names) != 1 {
+					return usageError("user needs add <user>")
+				}
+				store, err := auth.OpenUserStore(c.path(c.settings.Data.UsersFile))
+				if err != nil {
+					return err
+				}
+				password, err := readPassword()
+				if err != nil {
+					return err
+				}
+				user, err := store.Create(names[0], password, parseScopes(*scope))
+				if err != nil {
+					return fmt.Errorf("create user: %w", err)
+				}
+				fmt.Fprintf(c.stdout, "Created user %s\n", user.Name)
+				slog.InfoContext(c.ctx, "Created user", "User", user.Name, "Scopes", user.Scopes)
+				return nil
+			}
+		}}
+}
+
+// shareCommand gives a user a role on another user's list.
+func shareCommand() command {
+	return command{name: "share", args: "<user>", summary: "Share a user's list with another user", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		owner := fs.String("owner", "", "owner of the list (required)")
+		roleName := fs.String("role", "viewer", "role to give (viewer|editor|admin)")
+		return func(c *cli, args []string) error {
+			if len(args) != 1 || *owner == "" {
+				return usageError("share needs a user and -owner")
+			}
+			return manageShares(c.path(c.settings.Data.SharesFile), func(store *auth.ShareStore) error {
+				role, err := auth.ParseRole(*roleName)
+				if err != nil {
+					return err
+				}
+				share, err := store.Set(*owner, args[0], role)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(c.stdout, "Shared the list of %s with %s as %s\n", share.List, share.User, share.Role)
+				slog.InfoContext(c.ctx, "Shared list", "List", share.List, "User", share.User, "Role", share.Role)
+				return nil
+			})
+		}
+	}}
+}
+
+// unshareCommand takes a user's role on another user's list away.
+func unshareCommand() command {
+	return command{name: "unshare", args: "<user>", summary: "Stop sharing a user's list with another user", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		owner := fs.String("owner", "", "owner of the list (required)")
+		return func(c *cli, args []string) error {
+			if len(args) != 1 || *owner == "" {
+				return usageError("unshare needs a user and -owner")
+			}
+			return manageShares(c.path(c.settings.Data.SharesFile), func(store *auth.ShareStore) error {
+				if err := store.Remove(*owner, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.stdout, "Stopped sharing the list of %s with %s\n", *owner, args[0])
+				slog.InfoContext(c.ctx, "Unshared list", "List", *owner, "User", args[0])
+				return nil
+			})
+		}
+	}}
+}
+
+// sharesCommand lists who a user's list is shared with.
+func sharesCommand() command {
+	return command{name: "shares", args: "<list owner>", summary: "List who a user's list is shared with", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		return func(c *cli, args []string) error {
+			if len(args) != 1 {
+				return usageError("shares needs the owner of the list")
+			}
+			return manageShares(c.path(c.settings.Data.SharesFile), func(store *auth.ShareStore) error {
+				shares, err := store.Shares(args[0])
+				if err != nil {
+					return err
+				}
+				for _, share := range shares {
+					fmt.Fprintf(c.stdout, "%-16s  %-8s  %s\n", share.User, share.Role, share.Created.Format("2006-01-02 15:04"))
+				}
+				return nil
+			})
+		}
+	}}
+}
+
+// scopeFlag defines the -scope flag of the token and user commands.
+func scopeFlag(fs *flag.FlagSet) *string {
+	return fs.String("scope", "", "comma separated scopes (\"admin\" can see and change all items, \"metrics\" can only read /metrics)")
+}
+
+// maxDescriptionFlag defines the -max-description flag of the commands that validate descriptions.
+func maxDescriptionFlag(fs *flag.FlagSet) *int {
+	return fs.Int("max-description", storage.DefaultMaxDescriptionLength, "longest item description accepted, in characters")
+}
+
+// firstArg splits off the first positional argument, such as the action of the token command.
+func firstArg(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return args[0], args[1:]
+}
+
+// manageTokens opens the token store and runs a token command against it.
+func manageTokens(file string, run func(store *auth.TokenStore) error) error {
+	store, err := auth.OpenTokenStore(file)
+	if err != nil {
+		return err
+	}
+	return run(store)
+}
+
+// manageShares opens the share store and runs a share command against it.
+func manageShares(file string, run func(store *auth.ShareStore) error) error {
+	store, err := auth.OpenShareStore(file)
+	if err != nil {
+		return err
+	}
+	return run(store)
+}
+
+// parseScopes splits a comma separated -scope value.
+func parseScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// readPassword prompts for a password and reads it from the first line of stdin,
+// without echoing it when stdin is a terminal.
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	if restore, err := terminal.MakeRaw(os.Stdin, os.Stderr); err == nil {
+		password, err := readTypedPassword(bufio.NewReader(os.Stdin))
+		restore()
+		fmt.Fprintln(os.Stderr)
+		return password, err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no password given: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readTypedPassword reads a password typed on a raw terminal up to Enter,
+// handling the backspace, Ctrl-C and Ctrl-D keys the terminal no longer handles itself.
+func readTypedPassword(r *bufio.Reader) (string, error) {
+	var password []rune
+	for {
+		key, _, err := r.ReadRune()
+		if err != nil && len(password) == 0 {
+			return "", fmt.Errorf("no password given: %w", err)
+		}
+		if err != nil {
+			return string(password), nil
+		}
+		switch key {
+		case '\r', '\n':
+			return string(password), nil
+		case 0x03:
+			return "", errors.New("password entry cancelled")
+		case 0x04:
+			if len(password) == 0 {
+				return "", fmt.Errorf("no password given: %w", io.EOF)
+			}
+		case 0x7f, '\b':
+			if len(password) > 0 {
+				password = password[:len(password)-1]
+			}
+		default:
+			password = append(password, key)
+		}
+	}
+}
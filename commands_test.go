@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setupDataFolder points the data folder at a temporary directory for the rest of the test.
+func setupDataFolder(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv(remoteEnv, "")
+	t.Setenv(configEnv, "")
+}
+
+// runCLI runs the command line and returns the exit code and output.
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// TestMain_ParseArgs tests that flags are accepted between and after positional arguments.
+func TestMain_ParseArgs(t *testing.T) {
+	tests := []struct {
+		args       []string
+		positional []string
+		status     string
+	}{
+		{[]string{"-status", "in_progress", "Buy", "milk"}, []string{"Buy", "milk"}, "in_progress"},
+		{[]string{"Buy", "-status", "in_progress", "milk"}, []string{"Buy", "milk"}, "in_progress"},
+		{[]string{"Buy", "milk", "-status=is_finished"}, []string{"Buy", "milk"}, "is_finished"},
+		{[]string{"Buy", "--", "-status", "x"}, []string{"Buy", "-status", "x"}, ""},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		status := fs.String("status", "", "")
+		positional, err := parseArgs(fs, tt.args)
+		if err != nil || !slices.Equal(positional, tt.positional) || *status != tt.status {
+			t.Errorf("parseArgs(%q) = %q, status %q, %v; want %q, status %q", tt.args, positional, *status, err, tt.positional, tt.status)
+		}
+	}
+}
+
+// TestMain_RunUsage tests the exit codes and messages for usage errors and help.
+func TestMain_RunUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{"no command", nil, exitUsage, "", "Commands:"},
+		{"unknown command", []string{"frobnicate"}, exitUsage, "", `Unknown command "frobnicate"`},
+		{"help", []string{"help"}, exitOK, "todo <command>", ""},
+		{"help command", []string{"help", "edit"}, exitOK, "Usage: todo edit [flags] <id> [new description]", ""},
+		{"command -h", []string{"add", "-h"}, exitOK, "", "-status"},
+		{"unknown flag", []string{"list", "-bogus"}, exitUsage, "", "flag provided but not defined: -bogus"},
+		{"missing argument", []string{"done"}, exitUsage, "", "done needs one item ID"},
+		{"invalid ID", []string{"rm", "abc"}, exitUsage, "", `invalid item ID "abc"`},
+		{"invalid status", []string{"add", "-status", "done", "Buy milk"}, exitUsage, "", `invalid status "done"`},
+		{"token action", []string{"token", "rotate"}, exitUsage, "", "token needs create <user>, list or revoke <token id>"},
+		{"share owner", []string{"share", "bob"}, exitUsage, "", "share needs a user and -owner"},
+		{"tui arguments", []string{"tui", "now"}, exitUsage, "", "tui takes no arguments"},
+		{"shell arguments", []string{"shell", "now"}, exitUsage, "", "shell takes no arguments"},
+	}
+	setupDataFolder(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tt.args...)
+			if code != tt.code || !strings.Contains(stdout, tt.stdout) || !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("got exit %d\nstdout: %s\nstderr: %s", code, stdout, stderr)
+			}
+		})
+	}
+}
+
+// TestMain_RunUsageListsCommands tests that the overview names every command except the hidden ones.
+func TestMain_RunUsageListsCommands(t *testing.T) {
+	setupDataFolder(t)
+	_, stdout, _ := runCLI(t, "help")
+	for _, cmd := range commands() {
+		if cmd.hidden {
+			if strings.Contains(stdout, cmd.name) {
+				t.Errorf("expected hidden command %s left out of the usage text", cmd.name)
+			}
+			continue
+		}
+		if !strings.Contains(stdout, "  "+cmd.name+" ") {
+			t.Errorf("expected %s in the usage text:\n%s", cmd.name, stdout)
+		}
+	}
+}
+
+// TestMain_TokenCommands tests creating, listing and revoking tokens through the CLI.
+func TestMain_TokenCommands(t *testing.T) {
+	setupDataFolder(t)
+	code, stdout, stderr := runCLI(t, "token", "create", "alice", "-scope", "admin")
+	if code != exitOK || !strings.Contains(stdout, "Created token") {
+		t.Fatalf("token create failed: %d %s %s", code, stdout, stderr)
+	}
+	id := strings.Fields(stdout)[2]
+	if code, stdout, _ := runCLI(t, "token", "list"); code != exitOK || !strings.Contains(stdout, id) || !strings.Contains(stdout, "admin") {
+		t.Errorf("token list failed: %d %s", code, stdout)
+	}
+	if code, _, _ := runCLI(t, "token", "revoke", id); code != exitOK {
+		t.Errorf("token revoke failed: %d", code)
+	}
+	if code, _, stderr := runCLI(t, "token", "revoke", id); code != exitFailed || !strings.HasPrefix(stderr, "Error:") {
+		t.Errorf("expected revoking twice to fail, got %d %s", code, stderr)
+	}
+}
+
+// TestMain_UserCommand tests creating a login with the password piped on stdin.
+func TestMain_UserCommand(t *testing.T) {
+	setupDataFolder(t)
+	in, out, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe failed: %v", err)
+	}
+	stdin := os.Stdin
+	os.Stdin = in
+	t.Cleanup(func() { os.Stdin = stdin; in.Close() })
+	out.WriteString("correct horse battery\n")
+	out.Close()
+
+	if code, stdout, stderr := runCLI(t, "user", "add", "bob"); code != exitOK || !strings.Contains(stdout, "Created user bob") {
+		t.Fatalf("user add failed: %d %s %s", code, stdout, stderr)
+	}
+	if code, _, _ := runCLI(t, "user", "add"); code != exitUsage {
+		t.Errorf("expected a usage error without a user, got %d", code)
+	}
+}
+
+// TestMain_ReadTypedPassword tests the line editing done while a password is typed without echo.
+func TestMain_ReadTypedPassword(t *testing.T) {
+	tests := []struct {
+		input    string
+		password string
+		fails    bool
+	}{
+		{"secret\r", "secret", false},
+		{"secret\n", "secret", false},
+		{"secrex\x7ft\r", "secret", false},
+		{"\x7f\x7fab\bc\r", "ac", false},
+		{"sec\x03ret\r", "", true},
+		{"\x04", "", true},
+		{"", "", true},
+		{"secret", "secret", false},
+	}
+	for _, tt := range tests {
+		password, err := readTypedPassword(bufio.NewReader(strings.NewReader(tt.input)))
+		if (err != nil) != tt.fails || password != tt.password {
+			t.Errorf("%q: got %q %v", tt.input, password, err)
+		}
+	}
+}
+
+// TestMain_FlagCommands tests that the flag forms of commands run the same command as their names.
+func TestMain_FlagCommands(t *testing.T) {
+	tests := []struct {
+		flagArgs []string
+		args     []string
+	}{
+		{[]string{"-tui", "-h"}, []string{"tui", "-h"}},
+		{[]string{"--tui", "-h"}, []string{"tui", "-h"}},
+		{[]string{"-shell", "-h"}, []string{"shell", "-h"}},
+		{[]string{"--shell", "-h"}, []string{"shell", "-h"}},
+		{[]string{"-completion", "bash"}, []string{"completion", "bash"}},
+		{[]string{"--completion", "fish"}, []string{"completion", "fish"}},
+	}
+	for _, tt := range tests {
+		wantCode, wantStdout, wantStderr := runCLI(t, tt.args...)
+		code, stdout, stderr := runCLI(t, tt.flagArgs...)
+		if code != wantCode || stdout != wantStdout || stderr != wantStderr {
+			t.Errorf("run(%q) = %d, %q, %q; want %d, %q, %q", tt.flagArgs, code, stdout, stderr, wantCode, wantStdout, wantStderr)
+		}
+	}
+}
+
+// TestMain_Config tests that the config file, environment and -config flag reach the commands and print-config.
+func TestMain_Config(t *testing.T) {
+	setupDataFolder(t)
+	file := os.Getenv("XDG_CONFIG_HOME") + "\\" + configfolder + "\\config.toml"
+	if err := os.WriteFile(file, []byte("[server]\naddr = \":9000\"\n[log]\nlevel = \"warn\"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("TODO_LOG_LEVEL", "debug")
+
+	code, stdout, stderr := runCLI(t, "-print-config")
+	if code != exitOK {
+		t.Fatalf("print-config failed: %d %s", code, stderr)
+	}
+	for _, line := range []string{
+		"# config file " + file,
+		`addr = ":9000" # file ` + file,
+		`level = "debug" # env TODO_LOG_LEVEL`,
+		`folder = "tododata" # default`,
+	} {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("expected %q in:\n%s", line, stdout)
+		}
+	}
+
+	// data settings move the files the commands use
+	folder := t.TempDir()
+	t.Setenv("TODO_DATA_FOLDER", folder)
+	t.Setenv("TODO_DATA_ITEMS_FILE", "items.json")
+	if code, _, stderr := runCLI(t, "add", "Buy milk"); code != exitOK {
+		t.Fatalf("add failed: %d %s", code, stderr)
+	}
+	if _, err := os.Stat(folder + "\\items.json"); err != nil {
+		t.Errorf("expected the items file in the configured folder: %v", err)
+	}
+
+	if code, _, stderr := runCLI(t, "list", "-config", folder+"\\missing.json"); code != exitFailed || !strings.Contains(stderr, "Config failed to load") {
+		t.Errorf("expected a missing -config file to fail, got %d %s", code, stderr)
+	}
+	t.Setenv("TODO_LOG_FORMAT", "xml")
+	if code, _, stderr := runCLI(t, "list"); code != exitFailed || !strings.Contains(stderr, `log format "xml"`) {
+		t.Errorf("expected an invalid environment value to fail, got %d %s", code, stderr)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/auth"
+	"todo-app/render"
+	"todo-app/storage"
+)
+
+// completeTimeout bounds reading the items for a completion, so a slow server does not hang the shell.
+const completeTimeout = 2 * time.Second
+
+// completionScripts are the completion scripts by shell. Each calls "todo __complete -- <words>"
+// with the words typed after the program name, the last one being the word to complete,
+// and gets back one candidate per line, optionally followed by a tab and a description.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for todo, load with: source <(todo completion bash)
+_todo_complete() {
+    local IFS=$'\n'
+    local candidates
+    candidates=$(todo __complete -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null | cut -f1)
+    COMPREPLY=($(compgen -W "$candidates" -- "${COMP_WORDS[COMP_CWORD]}"))
+}
+complete -F _todo_complete todo
+`,
+	"zsh": `#compdef todo
+# zsh completion for todo, load with: source <(todo completion zsh)
+_todo() {
+    local -a candidates
+    local line value
+    for line in "${(@f)$(todo __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -n $line ]] || continue
+        value=${${line%%$'\t'*}//:/\\:}
+        if [[ $line == *$'\t'* ]]; then
+            candidates+=("$value:${line#*$'\t'}")
+        else
+            candidates+=("$value")
+        fi
+    done
+    _describe 'todo' candidates
+}
+compdef _todo todo
+`,
+	"fish": `# fish completion for todo, load with: todo completion fish | source
+function __todo_complete
+    set -l words (commandline -opc) (commandline -ct)
+    todo __complete -- $words[2..-1] 2>/dev/null
+end
+complete -c todo -f -a '(__todo_complete)'
+`,
+}
+
+// completionShells lists the shells with a completion script.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// flagValues are the values completed after a flag, by flag name.
+var flagValues = map[string][]string{
+	"status": storage.Statuses,
+	"output": render.Formats,
+	"scope":  {auth.ScopeAdmin},
+	"role":   {string(auth.RoleViewer), string(auth.RoleEditor), string(auth.RoleAdmin)},
+}
+
+// completionCommand prints a completion script.
+func completionCommand() command {
+	return command{name: "completion", args: strings.Join(completionShells, "|"),
+		summary: "Print the shell completion script for commands, flags, item IDs and statuses\nLoad it with \"source <(todo completion bash)\", \"source <(todo completion zsh)\" or \"todo completion fish | source\".",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			return func(c *cli, args []string) error {
+				if len(args) != 1 {
+					return usageError("completion needs a shell: " + strings.Join(completionShells, ", "))
+				}
+				script, ok := completionScripts[args[0]]
+				if !ok {
+					return usageError(fmt.Sprintf("no completion for %q, use %s", args[0], strings.Join(completionShells, ", ")))
+				}
+				_, err := io.WriteString(c.stdout, script)
+				return err
+			}
+		}}
+}
+
+// completeCommand is called back by the completion scripts.
+func completeCommand() command {
+	return command{name: "__complete", args: "-- <words>", hidden: true,
+		summary: "Print the candidates for the last of the words typed after the program name",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			return func(c *cli, args []string) error {
+				if len(args) == 0 {
+					args = []string{""}
+				}
+				current := args[len(args)-1]
+				for _, candidate := range completions(c, args[:len(args)-1], current) {
+					if strings.HasPrefix(candidate, current) {
+						fmt.Fprintln(c.stdout, candidate)
+					}
+				}
+				return nil
+			}
+		}}
+}
+
+// completions returns the candidates for the word being typed after the typed words,
+// each optionally followed by a tab and a description.
+func completions(c *cli, typed []string, current string) []string {
+	if len(typed) == 0 {
+		return commandCandidates()
+	}
+	if typed[0] == "help" {
+		if len(typed) == 1 {
+			return commandCandidates()
+		}
+		return nil
+	}
+	cmd, ok := findCommand(typed[0])
+	if !ok || cmd.hidden {
+		return nil
+	}
+	fs := newFlagSet(cmd, io.Discard)
+	cmd.setup(fs)
+	args := typed[1:]
+
+	// the value of a flag given as "-flag value" or "-flag=value"
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "-") {
+		if f := fs.Lookup(strings.TrimLeft(args[len(args)-1], "-")); f != nil && !isBoolFlag(f) {
+			return flagValues[f.Name]
+		}
+	}
+	if strings.HasPrefix(current, "-") {
+		if name, _, found := strings.Cut(strings.TrimLeft(current, "-"), "="); found {
+			var candidates []string
+			for _, value := range flagValues[name] {
+				candidates = append(candidates, current[:strings.Index(current, "=")+1]+value)
+			}
+			return candidates
+		}
+		return flagCandidates(fs)
+	}
+
+	positional, _ := parseArgs(fs, args)
+	return argCandidates(c, cmd.name, len(positional), fs)
+}
+
+// argCandidates completes positional argument n of a command.
+func argCandidates(c *cli, name string, n int, fs *flag.FlagSet) []string {
+	switch {
+	case n == 0 && slices.Contains([]string{"get", "done", "edit", "rm"}, name):
+		return itemCandidates(c, fs)
+	case n == 0 && name == "token":
+		return []string{"create\tcreate a token for a user", "list\tlist the tokens", "revoke\trevoke a token by ID"}
+	case n == 0 && name == "completion":
+		return completionShells
+	}
+	return nil
+}
+
+// itemCandidates returns the item IDs with their descriptions, from the data file or the server given with -remote.
+func itemCandidates(c *cli, fs *flag.FlagSet) []string {
+	remote := fs.Lookup("remote").Value.String()
+	items, err := openItems(c, itemFlags{remote: &remote})
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, completeTimeout)
+	defer cancel()
+	list, err := items.ListAll(ctx)
+	if err != nil {
+		return nil
+	}
+	ids := make([]int, 0, len(list))
+	for id := range list {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	var candidates []string
+	for _, id := range ids {
+		item := list[id]
+		candidates = append(candidates, strconv.Itoa(id)+"\t"+item.Status+": "+item.Description)
+	}
+	return candidates
+}
+
+// commandCandidates returns the command names with their summaries.
+func commandCandidates() []string {
+	var candidates []string
+	for _, cmd := range commands() {
+		if !cmd.hidden {
+			summary, _, _ := strings.Cut(cmd.summary, "\n")
+			candidates = append(candidates, cmd.name+"\t"+summary)
+		}
+	}
+	return append(candidates, "help\tshow the commands or the flags of a command")
+}
+
+// flagCandidates returns the flags of a command with their usage.
+func flagCandidates(fs *flag.FlagSet) []string {
+	var candidates []string
+	fs.VisitAll(func(f *flag.Flag) {
+		candidates = append(candidates, "-"+f.Name+"\t"+f.Usage)
+	})
+	return candidates
+}
+
+// isBoolFlag reports whether a flag takes no value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"todo-app/internal/itemstest"
+	"todo-app/storage"
+)
+
+// TestMain_CompletionScripts tests that every shell gets a script calling back into __complete.
+func TestMain_CompletionScripts(t *testing.T) {
+	setupDataFolder(t)
+	for _, shell := range completionShells {
+		code, stdout, stderr := runCLI(t, "completion", shell)
+		if code != exitOK || !strings.Contains(stdout, "todo __complete -- ") {
+			t.Errorf("%s: got exit %d\n%s%s", shell, code, stdout, stderr)
+		}
+	}
+	if code, _, stderr := runCLI(t, "completion", "powershell"); code != exitUsage || !strings.Contains(stderr, `no completion for "powershell"`) {
+		t.Errorf("expected a usage error for an unknown shell, got %d %s", code, stderr)
+	}
+}
+
+// TestMain_Complete tests the candidates printed for partly typed command lines.
+func TestMain_Complete(t *testing.T) {
+	setupDataFolder(t)
+	useFakeItems(t, itemstest.New(
+		storage.Item{ID: 1, Description: "Buy milk", Status: "in_progress"},
+		storage.Item{ID: 12, Description: "Walk the dog", Status: "not_started"},
+	))
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"sh"}, []string{"shell", "share", "shares"}},
+		{[]string{"__"}, nil},
+		{[]string{"help", "com"}, []string{"completion"}},
+		{[]string{"done", ""}, []string{"1", "12"}},
+		{[]string{"rm", "1"}, []string{"1", "12"}},
+		{[]string{"edit", "1", ""}, nil},
+		{[]string{"add", "-st"}, []string{"-status"}},
+		{[]string{"list", "-status", "i"}, []string{"in_progress", "is_finished"}},
+		{[]string{"list", "-output=c"}, []string{"-output=csv"}},
+		{[]string{"serve", "-tls-self-signed", ""}, nil},
+		{[]string{"share", "bob", "-role", ""}, []string{"viewer", "editor", "admin"}},
+		{[]string{"token", "r"}, []string{"revoke"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		{[]string{"bogus", ""}, nil},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runCLI(t, append([]string{"__complete", "--"}, tt.words...)...)
+		var got []string
+		for line := range strings.Lines(stdout) {
+			value, _, _ := strings.Cut(strings.TrimSuffix(line, "\n"), "\t")
+			got = append(got, value)
+		}
+		if code != exitOK || !slices.Equal(got, tt.want) {
+			t.Errorf("%q: got exit %d, %q, want %q\n%s", tt.words, code, got, tt.want, stderr)
+		}
+	}
+	if _, stdout, _ := runCLI(t, "__complete", "--", "get", ""); !strings.Contains(stdout, "12\tnot_started: Walk the dog\n") {
+		t.Errorf("expected item descriptions with the IDs:\n%s", stdout)
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"todo-app/logging"
+)
+
+// UnixPrefix marks an address as a Unix domain socket path, e.g. unix:/run/todo-app.sock.
+const UnixPrefix string = "unix:"
+
+// Duration is a time.Duration written as a string such as "30s" in the config file.
+type Duration time.Duration
+
+// Server holds the HTTP server settings.
+type Server struct {
+	Addr              string   `json:"addr"`
+	TLSCert           string   `json:"tls_cert,omitempty"`
+	TLSKey            string   `json:"tls_key,omitempty"`
+	TLSSelfSigned     bool     `json:"tls_self_signed,omitempty"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+	MaxHeaderBytes    int      `json:"max_header_bytes"`
+}
+
+// Data names the data folder and the files kept in it.
+type Data struct {
+	// Folder is created in the user cache directory, unless it is an absolute path.
+	Folder     string `json:"folder"`
+	ItemsFile  string `json:"items_file"`
+	LogFile    string `json:"log_file"`
+	TokensFile string `json:"tokens_file"`
+	UsersFile  string `json:"users_file"`
+	SharesFile string `json:"shares_file"`
+}
+
+// Log holds the options of the log file.
+type Log struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	Source bool   `json:"source"`
+}
+
+// Config is the layout of the config file.
+type Config struct {
+	Data   Data   `json:"data"`
+	Server Server `json:"server"`
+	Log    Log    `json:"log"`
+}
+
+// Default returns the settings used when neither the config file, the environment nor a flag sets them.
+func Default() Config {
+	return Config{
+		Data: Data{
+			Folder:     "tododata",
+			ItemsFile:  "todos.json",
+			LogFile:    "todos.log",
+			TokensFile: "tokens.json",
+			UsersFile:  "users.json",
+			SharesFile: "shares.json",
+		},
+		Server: Server{
+			Addr:              ":8080",
+			ReadTimeout:       Duration(30 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			MaxHeaderBytes:    64 << 10,
+		},
+		Log: Log{Level: "info", Format: "text"},
+	}
+}
+
+// Load reads the config file over the defaults. A missing file is only an error when required is set.
+func Load(file string, required bool) (Config, error) {
+	s := newSettings()
+	if err := s.readFile(file, required); err != nil {
+		return s.Config, err
+	}
+	return s.Config, s.Validate()
+}
+
+// Validate checks every section.
+func (c Config) Validate() error {
+	return errors.Join(c.Data.Validate(), c.Server.Validate(), c.Log.Validate())
+}
+
+// Validate checks the folder and file names are set and the files are plain names inside the folder.
+func (d Data) Validate() error {
+	if d.Folder == "" {
+		return errors.New("data folder cannot be empty")
+	}
+	for _, file := range []string{d.ItemsFile, d.LogFile, d.TokensFile, d.UsersFile, d.SharesFile} {
+		if file == "" || strings.ContainsAny(file, `/\`) {
+			return fmt.Errorf("data file name %q must be a plain file name", file)
+		}
+	}
+	return nil
+}
+
+// Validate checks the level and format are known to the logger.
+func (l Log) Validate() error {
+	if _, err := logging.LoggerOptions(l.Level, l.Source); err != nil {
+		return err
+	}
+	if !slices.Contains(logging.LogFormats, l.Format) {
+		return fmt.Errorf("log format %q: use %s", l.Format, strings.Join(logging.LogFormats, " or "))
+	}
+	return nil
+}
+
+// Validate checks the settings are consistent.
+func (s Server) Validate() error {
+	if s.Addr == "" || s.Addr == UnixPrefix {
+		return errors.New("server address cannot be empty")
+	}
+	if (s.TLSCert == "") != (s.TLSKey == "") {
+		return errors.New("tls_cert and tls_key must be set together")
+	}
+	if s.TLSSelfSigned && s.TLSCert != "" {
+		return errors.New("tls_self_signed cannot be combined with tls_cert")
+	}
+	return nil
+}
+
+// TLS reports whether the server is served over HTTPS.
+func (s Server) TLS() bool {
+	return s.TLSCert != "" || s.TLSSelfSigned
+}
+
+// UnixSocket returns the socket path when the address is a Unix domain socket.
+func (s Server) UnixSocket() (string, bool) {
+	return strings.CutPrefix(s.Addr, UnixPrefix)
+}
+
+// MarshalJSON writes the duration as a string such as "30s".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reads a duration string such as "30s".
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
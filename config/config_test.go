@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestConfig_LoadMissing tests that a missing optional file gives the defaults.
+func TestConfig_LoadMissing(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.json"), false)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Server.Addr != ":8080" || cfg.Server.WriteTimeout != Duration(30*time.Second) {
+		t.Errorf("Expected defaults, got %+v", cfg.Server)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "config.json"), true); err == nil {
+		t.Error("Expected error for missing required file")
+	}
+}
+
+// TestConfig_LoadFile tests that file values override the defaults and unset values keep them.
+func TestConfig_LoadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	data := `{"server": {"addr": "unix:/tmp/todo.sock", "idle_timeout": "5m"}}`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	cfg, err := Load(file, true)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if path, ok := cfg.Server.UnixSocket(); !ok || path != "/tmp/todo.sock" {
+		t.Errorf("Expected unix socket, got %q %v", path, ok)
+	}
+	if cfg.Server.IdleTimeout != Duration(5*time.Minute) || cfg.Server.ReadHeaderTimeout != Duration(5*time.Second) {
+		t.Errorf("Unexpected timeouts: %+v", cfg.Server)
+	}
+}
+
+// TestConfig_Invalid tests the rejected settings.
+func TestConfig_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"server": {"tls_cert": "cert.pem"}}`,
+		`{"server": {"tls_self_signed": true, "tls_cert": "cert.pem", "tls_key": "key.pem"}}`,
+		`{"server": {"read_timeout": 30}}`,
+		`{"server": {"addr": ""}}`,
+		`{"server": {"port": 8080}}`,
+		`{"data": {"items_file": "../todos.json"}}`,
+		`{"log": {"level": "loud"}}`,
+		`{"log": {"format": "xml"}}`,
+	} {
+		file := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if _, err := Load(file, true); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the environment variables read by Resolve, e.g. TODO_SERVER_ADDR for server.addr.
+const EnvPrefix string = "TODO_"
+
+// Settings are the effective config values, with the layer each one came from.
+type Settings struct {
+	Config
+	// File is the config file read, empty when there was none.
+	File    string
+	sources map[string]string
+}
+
+// newSettings returns the defaults.
+func newSettings() *Settings {
+	return &Settings{Config: Default(), sources: map[string]string{}}
+}
+
+// Resolve layers the config file and then the TODO_* variables of environ over the defaults.
+// Flags are layered on top with Set. An empty file name skips the file; a missing file is only an error when required is set.
+func Resolve(file string, required bool, environ []string) (*Settings, error) {
+	s := newSettings()
+	if file != "" {
+		if err := s.readFile(file, required); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range Keys() {
+		name := EnvName(key)
+		for _, entry := range environ {
+			if value, ok := strings.CutPrefix(entry, name+"="); ok {
+				if err := s.Set(key, value, "env "+name); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// readFile reads a config file over the current values, as TOML when it ends in .toml and as JSON otherwise.
+func (s *Settings) readFile(file string, required bool) error {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	source := "file " + file
+	if strings.HasSuffix(file, ".toml") {
+		values, err := parseTOML(data)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", file, err)
+		}
+		for _, value := range values {
+			if err := s.Set(value.key, value.text, source); err != nil {
+				return fmt.Errorf("config file %s line %d: %w", file, value.line, err)
+			}
+		}
+	} else {
+		var sections map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(data, &sections); err != nil {
+			return fmt.Errorf("config file %s: %w", file, err)
+		}
+		for section, values := range sections {
+			for name, raw := range values {
+				key := section + "." + name
+				field, err := s.field(key)
+				if err == nil {
+					err = json.Unmarshal(raw, field.Addr().Interface())
+				}
+				if err != nil {
+					return fmt.Errorf("config file %s: %s: %w", file, key, err)
+				}
+				s.sources[key] = source
+			}
+		}
+	}
+	s.File = file
+	return nil
+}
+
+// Set sets a value given as text, such as "30s" for a duration, and records its source, e.g. "flag -addr".
+func (s *Settings) Set(key string, value string, source string) error {
+	field, err := s.field(key)
+	if err != nil {
+		return err
+	}
+	switch field.Interface().(type) {
+	case Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		field.Set(reflect.ValueOf(Duration(d)))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", key, value)
+		}
+		field.SetInt(int64(n))
+	default:
+		field.SetString(value)
+	}
+	s.sources[key] = source
+	return nil
+}
+
+// Source returns where the value of a key came from: "default", "file <name>", "env <name>" or "flag <name>".
+func (s *Settings) Source(key string) string {
+	if source, ok := s.sources[key]; ok {
+		return source
+	}
+	return "default"
+}
+
+// Value returns the value of a key as it is written in a TOML file.
+func (s *Settings) Value(key string) string {
+	field, err := s.field(key)
+	if err != nil {
+		return ""
+	}
+	switch value := field.Interface().(type) {
+	case Duration:
+		return strconv.Quote(time.Duration(value).String())
+	case string:
+		return strconv.Quote(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Print writes the effective values in the TOML format read by Resolve, each followed by its source.
+func (s *Settings) Print(w io.Writer) error {
+	var b bytes.Buffer
+	if s.File != "" {
+		fmt.Fprintf(&b, "# config file %s\n", s.File)
+	} else {
+		fmt.Fprint(&b, "# no config file\n")
+	}
+	section := ""
+	for _, key := range Keys() {
+		name, field, _ := strings.Cut(key, ".")
+		if name != section {
+			section = name
+			fmt.Fprintf(&b, "\n[%s]\n", section)
+		}
+		fmt.Fprintf(&b, "%s = %s # %s\n", field, s.Value(key), s.Source(key))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Keys returns the keys of every setting, as section.name, in the order of the Config fields.
+func Keys() []string {
+	var keys []string
+	sections := reflect.TypeFor[Config]()
+	for i := range sections.NumField() {
+		section := sections.Field(i)
+		for j := range section.Type.NumField() {
+			keys = append(keys, jsonName(section)+"."+jsonName(section.Type.Field(j)))
+		}
+	}
+	return keys
+}
+
+// EnvName returns the environment variable for a key, e.g. TODO_SERVER_ADDR for server.addr.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// field returns the settable field of a key.
+func (s *Settings) field(key string) (reflect.Value, error) {
+	name, fieldName, _ := strings.Cut(key, ".")
+	config := reflect.ValueOf(&s.Config).Elem()
+	for i := range config.NumField() {
+		if jsonName(config.Type().Field(i)) != name {
+			continue
+		}
+		section := config.Field(i)
+		for j := range section.NumField() {
+			if jsonName(section.Type().Field(j)) == fieldName {
+				return section.Field(j), nil
+			}
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown setting %q", key)
+}
+
+// jsonName returns the name a struct field has in the config file.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConfig_ResolveLayers tests that the environment overrides the file, which overrides the defaults, and that Set overrides both.
+func TestConfig_ResolveLayers(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	data := `{"server": {"addr": ":9000", "idle_timeout": "5m"}, "log": {"level": "debug"}}`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	s, err := Resolve(file, true, []string{"HOME=/home/me", "TODO_SERVER_ADDR=:9100", "TODO_LOG_SOURCE=true", "TODO_REMOTE=http://host"})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if s.Server.Addr != ":9100" || s.Source("server.addr") != "env TODO_SERVER_ADDR" {
+		t.Errorf("Expected the environment to win, got %q from %s", s.Server.Addr, s.Source("server.addr"))
+	}
+	if s.Server.IdleTimeout != Duration(5*time.Minute) || s.Source("server.idle_timeout") != "file "+file {
+		t.Errorf("Expected the file value, got %v from %s", s.Server.IdleTimeout, s.Source("server.idle_timeout"))
+	}
+	if s.Log.Level != "debug" || !s.Log.Source || s.Data.Folder != "tododata" || s.Source("data.folder") != "default" {
+		t.Errorf("Unexpected settings %+v", s.Config)
+	}
+
+	if err := s.Set("server.addr", "unix:/tmp/todo.sock", "flag -addr"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if s.Server.Addr != "unix:/tmp/todo.sock" || s.Source("server.addr") != "flag -addr" {
+		t.Errorf("Expected the flag to win, got %q from %s", s.Server.Addr, s.Source("server.addr"))
+	}
+}
+
+// TestConfig_ResolveErrors tests that unknown keys, bad values and bad environment variables are rejected.
+func TestConfig_ResolveErrors(t *testing.T) {
+	if _, err := Resolve(filepath.Join(t.TempDir(), "config.json"), true, nil); err == nil {
+		t.Error("Expected error for missing required file")
+	}
+	if _, err := Resolve("", true, nil); err != nil {
+		t.Errorf("Expected no file to give the defaults, got %v", err)
+	}
+	for _, environ := range [][]string{
+		{"TODO_SERVER_READ_TIMEOUT=30"},
+		{"TODO_SERVER_MAX_HEADER_BYTES=lots"},
+		{"TODO_SERVER_TLS_SELF_SIGNED=maybe"},
+		{"TODO_LOG_LEVEL=loud"},
+	} {
+		if _, err := Resolve("", false, environ); err == nil {
+			t.Errorf("Expected error for %v", environ)
+		}
+	}
+
+	s := newSettings()
+	if err := s.Set("server.port", "80", "flag -port"); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Errorf("Expected unknown setting error, got %v", err)
+	}
+}
+
+// TestConfig_Print tests that the printed settings read back as a TOML file with the same values.
+func TestConfig_Print(t *testing.T) {
+	s, err := Resolve("", false, []string{"TODO_SERVER_ADDR=:9100", "TODO_DATA_FOLDER=/srv/todo data"})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	var out strings.Builder
+	if err := s.Print(&out); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	for _, line := range []string{
+		"# no config file",
+		"[data]\nfolder = \"/srv/todo data\" # env TODO_DATA_FOLDER\n",
+		"addr = \":9100\" # env TODO_SERVER_ADDR\n",
+		"read_timeout = \"30s\" # default\n",
+		"max_header_bytes = 65536 # default\n",
+		"[log]\nlevel = \"info\" # default\nformat = \"text\" # default\nsource = false # default\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, out.String())
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(file, []byte(out.String()), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	read, err := Resolve(file, true, nil)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if read.Config != s.Config {
+		t.Errorf("Expected %+v read back, got %+v", s.Config, read.Config)
+	}
+}
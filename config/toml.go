@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tomlValue is one key = value line of a TOML file, with the value as text for Settings.Set.
+type tomlValue struct {
+	key  string
+	text string
+	line int
+}
+
+// parseTOML reads the part of TOML the config needs: [section] headers, key = value lines
+// with quoted strings, numbers and booleans, and # comments.
+func parseTOML(data []byte) ([]tomlValue, error) {
+	var values []tomlValue
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			name, rest, ok := strings.Cut(name, "]")
+			if !ok || strings.TrimSpace(name) == "" || !isComment(rest) {
+				return nil, fmt.Errorf("line %d: bad section header %q", n, line)
+			}
+			section = strings.TrimSpace(name)
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected key = value, got %q", n, line)
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: %s is not in a [section]", n, key)
+		}
+		text, err := tomlText(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		values = append(values, tomlValue{key: section + "." + key, text: text, line: n})
+	}
+	return values, scanner.Err()
+}
+
+// tomlText returns the text of a value, unquoting strings and dropping a trailing comment.
+func tomlText(value string) (string, error) {
+	if strings.HasPrefix(value, `"`) {
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil || !isComment(value[len(quoted):]) {
+			return "", fmt.Errorf("bad string %s", value)
+		}
+		return strconv.Unquote(quoted)
+	}
+	if text, _, _ := strings.Cut(value, "#"); strings.TrimSpace(text) != "" {
+		return strings.TrimSpace(text), nil
+	}
+	return "", errors.New("missing value")
+}
+
+// isComment reports whether the rest of a line is blank or a comment.
+func isComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
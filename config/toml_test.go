@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestConfig_TOML tests sections, quoted strings, numbers, booleans and comments.
+func TestConfig_TOML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	data := `# todo-app settings
+[server]
+addr = "unix:/tmp/todo #1.sock" # a socket
+max_header_bytes = 1024
+tls_self_signed = true
+write_timeout = "1m"
+
+[ log ]
+level = "warn"
+`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	cfg, err := Load(file, true)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Server.Addr != "unix:/tmp/todo #1.sock" || cfg.Server.MaxHeaderBytes != 1024 || !cfg.Server.TLSSelfSigned {
+		t.Errorf("Unexpected server settings %+v", cfg.Server)
+	}
+	if cfg.Server.WriteTimeout != Duration(time.Minute) || cfg.Log.Level != "warn" {
+		t.Errorf("Unexpected settings %+v", cfg)
+	}
+}
+
+// TestConfig_TOMLInvalid tests the rejected lines.
+func TestConfig_TOMLInvalid(t *testing.T) {
+	for _, data := range []string{
+		"addr = \":80\"\n",
+		"[server\naddr = \":80\"\n",
+		"[server]\naddr\n",
+		"[server]\naddr = \":80\" extra\n",
+		"[server]\naddr = \n",
+		"[server]\nport = 80\n",
+	} {
+		if _, err := parseTOMLFile(t, data); err == nil {
+			t.Errorf("Expected error for %q", data)
+		}
+	}
+}
+
+// parseTOMLFile writes data to a TOML file and loads it.
+func parseTOMLFile(t *testing.T, data string) (Config, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return Load(file, true)
+}
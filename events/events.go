@@ -0,0 +1,155 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"todo-app/storage"
+)
+
+const (
+	ItemCreated string = "item.created"
+	ItemUpdated string = "item.updated"
+	ItemDeleted string = "item.deleted"
+)
+
+const (
+	// DefaultBufferSize is the number of recent events kept for Last-Event-ID resume.
+	DefaultBufferSize int = 256
+	// subscriberBuffer is the number of events queued per subscriber before it is dropped.
+	subscriberBuffer int = 64
+)
+
+type Event struct {
+	ID   uint64       `json:"id"`
+	Type string       `json:"type"`
+	Item storage.Item `json:"item"`
+	Time time.Time    `json:"time"`
+}
+
+type Broker struct {
+	mu sync.Mutex
+	// epoch is random per broker, so stream IDs from before a restart never match the new ones
+	epoch       string
+	nextID      uint64
+	size        int
+	buffer      []Event
+	subscribers map[chan Event]struct{}
+}
+
+// NewBroker creates a Broker that keeps the last size events for resume.
+func NewBroker(size int) *Broker {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	epoch := make([]byte, 4)
+	_, _ = rand.Read(epoch)
+	return &Broker{
+		epoch:       hex.EncodeToString(epoch),
+		size:        size,
+		buffer:      make([]Event, 0, size),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish records a new event and fans it out to all subscribers.
+// Subscribers that cannot keep up are closed and removed; they can resume using the last event ID they saw.
+func (b *Broker) Publish(eventType string, item storage.Item) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// assign the next sequential id
+	b.nextID++
+	event := Event{ID: b.nextID, Type: eventType, Item: item, Time: time.Now().UTC()}
+
+	// keep the buffer bounded, dropping the oldest event
+	if len(b.buffer) == b.size {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:b.size-1]
+	}
+	b.buffer = append(b.buffer, event)
+
+	// fan out without blocking the publisher
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a new subscriber and returns the buffered events after lastID.
+// The returned bool is false when events after lastID have already been dropped from the buffer,
+// in which case the caller should refetch the full state.
+func (b *Broker) Subscribe(lastID uint64) ([]Event, chan Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	// nothing to replay for a fresh subscriber
+	if lastID == 0 {
+		return nil, ch, true
+	}
+
+	backlog := make([]Event, 0, len(b.buffer))
+	for _, event := range b.buffer {
+		if event.ID > lastID {
+			backlog = append(backlog, event)
+		}
+	}
+
+	// the buffer is complete when it still holds the event right after lastID,
+	// an id ahead of ours comes from a previous process and cannot be resumed
+	complete := lastID == b.nextID || (lastID < b.nextID && len(b.buffer) > 0 && b.buffer[0].ID <= lastID+1)
+	return backlog, ch, complete
+}
+
+// Unsubscribe removes the subscriber and closes its channel.
+func (b *Broker) Unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// StreamID returns the ID sent to stream clients for an event, as epoch-id.
+func (b *Broker) StreamID(id uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(id, 10)
+}
+
+// ParseStreamID returns the event ID of a stream ID sent by a reconnecting client.
+// The returned bool is false when the ID comes from another epoch, such as a process before a restart,
+// in which case the caller should refetch the full state.
+func (b *Broker) ParseStreamID(text string) (uint64, bool, error) {
+	epoch, number, ok := strings.Cut(text, "-")
+	if !ok {
+		// IDs without an epoch were sent before stream IDs had one
+		number = text
+	}
+	id, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid event ID %q", text)
+	}
+	if epoch != b.epoch {
+		return 0, false, nil
+	}
+	return id, true, nil
+}
+
+// LastID returns the id of the most recently published event.
+func (b *Broker) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"testing"
+	"todo-app/storage"
+)
+
+// TestEvents_PublishAssignsSequentialIDs tests that events get increasing IDs.
+func TestEvents_PublishAssignsSequentialIDs(t *testing.T) {
+	broker := NewBroker(10)
+	first := broker.Publish(ItemCreated, storage.Item{ID: 1})
+	second := broker.Publish(ItemUpdated, storage.Item{ID: 1})
+	if first.ID != 1 || second.ID != 2 {
+		t.Errorf("Expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+	if broker.LastID() != 2 {
+		t.Errorf("Expected LastID 2, got %d", broker.LastID())
+	}
+}
+
+// TestEvents_SubscribeReceivesPublished tests that subscribers receive new events.
+func TestEvents_SubscribeReceivesPublished(t *testing.T) {
+	broker := NewBroker(10)
+	backlog, ch, complete := broker.Subscribe(0)
+	defer broker.Unsubscribe(ch)
+	if len(backlog) != 0 || !complete {
+		t.Fatalf("Expected empty complete backlog, got %d events, complete %v", len(backlog), complete)
+	}
+
+	broker.Publish(ItemCreated, storage.Item{ID: 7, Description: "Test"})
+	event := <-ch
+	if event.Type != ItemCreated || event.Item.ID != 7 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+// TestEvents_SubscribeResume tests replaying buffered events after a Last-Event-ID.
+func TestEvents_SubscribeResume(t *testing.T) {
+	broker := NewBroker(10)
+	for i := 1; i <= 5; i++ {
+		broker.Publish(ItemCreated, storage.Item{ID: i})
+	}
+
+	backlog, ch, complete := broker.Subscribe(3)
+	defer broker.Unsubscribe(ch)
+	if !complete {
+		t.Error("Expected resume to be complete")
+	}
+	if len(backlog) != 2 || backlog[0].ID != 4 || backlog[1].ID != 5 {
+		t.Errorf("Unexpected backlog: %+v", backlog)
+	}
+}
+
+// TestEvents_BufferIsBounded tests that old events are dropped and resume reports the gap.
+func TestEvents_BufferIsBounded(t *testing.T) {
+	broker := NewBroker(3)
+	for i := 1; i <= 10; i++ {
+		broker.Publish(ItemCreated, storage.Item{ID: i})
+	}
+
+	backlog, ch, complete := broker.Subscribe(2)
+	defer broker.Unsubscribe(ch)
+	if complete {
+		t.Error("Expected resume to be incomplete after buffer overflow")
+	}
+	if len(backlog) != 3 || backlog[0].ID != 8 {
+		t.Errorf("Unexpected backlog: %+v", backlog)
+	}
+}
+
+// TestEvents_SubscribeFutureID tests that an ID from a previous process is reported as incomplete.
+func TestEvents_SubscribeFutureID(t *testing.T) {
+	broker := NewBroker(3)
+	broker.Publish(ItemCreated, storage.Item{ID: 1})
+
+	_, ch, complete := broker.Subscribe(50)
+	defer broker.Unsubscribe(ch)
+	if complete {
+		t.Error("Expected unknown future ID to be incomplete")
+	}
+}
+
+// TestEvents_StreamID tests that stream IDs round-trip within a broker and are rejected by another one.
+func TestEvents_StreamID(t *testing.T) {
+	broker := NewBroker(3)
+	other := NewBroker(3)
+
+	id, ok, err := broker.ParseStreamID(broker.StreamID(7))
+	if err != nil || !ok || id != 7 {
+		t.Errorf("Expected 7 from the same epoch, got %d %v %v", id, ok, err)
+	}
+	if broker.StreamID(7) == other.StreamID(7) {
+		t.Error("Expected brokers to have different epochs")
+	}
+	for _, text := range []string{other.StreamID(7), "7"} {
+		if _, ok, err := broker.ParseStreamID(text); err != nil || ok {
+			t.Errorf("Expected %q to be from another epoch, got %v %v", text, ok, err)
+		}
+	}
+	for _, text := range []string{"abc", broker.StreamID(0) + "x", ""} {
+		if _, _, err := broker.ParseStreamID(text); err == nil {
+			t.Errorf("Expected %q to be invalid", text)
+		}
+	}
+}
+
+// TestEvents_SlowSubscriberDropped tests that a full subscriber is closed instead of blocking the publisher.
+func TestEvents_SlowSubscriberDropped(t *testing.T) {
+	broker := NewBroker(10)
+	_, ch, _ := broker.Subscribe(0)
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		broker.Publish(ItemCreated, storage.Item{ID: i + 1})
+	}
+
+	count := 0
+	for range ch {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("Expected %d buffered events before close, got %d", subscriberBuffer, count)
+	}
+
+	// unsubscribing an already dropped subscriber must not panic
+	broker.Unsubscribe(ch)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"todo-app/auth"
+	"todo-app/storage"
+)
+
+const sessionCookieName string = "session"
+
+// tokenStore validates the bearer tokens sent to the API.
+var tokenStore *auth.TokenStore
+
+// userStore checks the passwords entered on the login page.
+var userStore *auth.UserStore
+
+// sessionStore holds the logged in web UI sessions.
+var sessionStore = auth.NewSessionStore(auth.SessionTTL, auth.SessionRotate)
+
+// publicPrefixes are served without authentication.
+var publicPrefixes = []string{"/about", "/static/", "/login", "/healthz", "/readyz", "/openapi.json", "/docs"}
+
+// InitAuth sets the token store used to authenticate API requests and the user store used by the login page.
+func InitAuth(tokens *auth.TokenStore, users *auth.UserStore) {
+	tokenStore = tokens
+	userStore = users
+	sessionStore = auth.NewSessionStore(auth.SessionTTL, auth.SessionRotate)
+}
+
+// authenticate requires a valid bearer token or login session on every route except the public pages,
+// and passes the caller's identity down to the actor through the request context.
+// Browsers without a session are sent to the login page.
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if tokenStore == nil {
+			http.Error(w, "Authentication not initialized", http.StatusInternalServerError)
+			return
+		}
+
+		// API clients send a bearer token
+		if header := r.Header.Get("Authorization"); header != "" {
+			scheme, secret, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				unauthorized(w)
+				return
+			}
+			identity, ok := tokenStore.Authenticate(strings.TrimSpace(secret))
+			if !ok {
+				unauthorized(w)
+				return
+			}
+			serveAs(w, r, identity, next)
+			return
+		}
+
+		// the web UI sends the session cookie
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			if session, ok := sessionStore.Touch(cookie.Value); ok {
+				setSessionCookie(w, r, session)
+				serveAs(w, r, session.Identity, next)
+				return
+			}
+		}
+
+		if isPagePath(r) {
+			http.Redirect(w, r, "/login?"+url.Values{"next": {r.URL.RequestURI()}}.Encode(), http.StatusSeeOther)
+			return
+		}
+		unauthorized(w)
+	})
+}
+
+// serveAs passes the request on with the caller's identity; identities with only the metrics scope
+// are kept to /metrics, so a scraper's token cannot read or change items.
+func serveAs(w http.ResponseWriter, r *http.Request, identity auth.Identity, next http.Handler) {
+	if identity.MetricsOnly() && r.URL.Path != "/metrics" {
+		http.Error(w, "Token is limited to /metrics", http.StatusForbidden)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+}
+
+// requireMetrics restricts a handler to callers with the metrics or admin scope.
+func requireMetrics(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.FromContext(r.Context())
+		if !ok || !identity.CanReadMetrics() {
+			http.Error(w, "Metrics or admin scope required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireAdmin restricts a handler to callers with the admin scope.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.FromContext(r.Context())
+		if !ok || !identity.IsAdmin() {
+			http.Error(w, "Admin scope required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// canSee reports whether the caller may see the item, used to filter the change feeds.
+func canSee(r *http.Request, item storage.Item) bool {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok || identity.IsAdmin() || item.Owner == identity.User {
+		return true
+	}
+	return shareStore != nil && item.Owner != "" && shareStore.Role(item.Owner, identity.User) != ""
+}
+
+// unauthorized writes a 401 asking for a bearer token.
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="todo-app"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// setSessionCookie sends the session ID, refreshing the cookie's expiry along with the session's.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, session auth.Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    session.ID,
+		Path:     "/",
+		Expires:  session.Expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie removes the session cookie from the browser.
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// isPagePath reports whether the request is a browser navigating to a web UI page.
+func isPagePath(r *http.Request) bool {
+	return r.Method == http.MethodGet && (r.URL.Path == "/list" || strings.HasPrefix(r.URL.Path, "/list/"))
+}
+
+// isPublicPath reports whether the path can be served without authentication.
+func isPublicPath(path string) bool {
+	for _, prefix := range publicPrefixes {
+		if path == strings.TrimSuffix(prefix, "/") || strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
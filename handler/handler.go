@@ -3,42 +3,133 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
-	"text/template"
+	"time"
 	"todo-app/actor"
+	"todo-app/auth"
+	"todo-app/events"
 	"todo-app/storage"
 )
 
 // ActorInterface defines the methods required by handlers
 type ActorInterface interface {
 	Create(ctx context.Context, description string, status string) (storage.Item, error)
+	CreateIn(ctx context.Context, list string, description string, status string) (storage.Item, error)
 	Update(ctx context.Context, id int, description string, status string) (storage.Item, error)
 	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, item storage.Item) (storage.Item, error)
 	ListAll(ctx context.Context) (storage.Items, error)
 	List(ctx context.Context, id int) (storage.Item, error)
+	Shares(ctx context.Context, list string) ([]auth.Share, error)
+	Share(ctx context.Context, list string, user string, role auth.Role) (auth.Share, error)
+	Unshare(ctx context.Context, list string, user string) error
+	Ping(ctx context.Context) error
+	CheckStorage(ctx context.Context) (storage.Health, error)
+}
+
+// errorBody is the JSON error sent when the actor denies an operation.
+type errorBody struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
 }
 
 var actorInstance ActorInterface
 
-// InitActor initializes the actor instance.
-func InitActor(ctx context.Context) {
-	actorInstance = actor.NewActor(ctx)
+// eventBroker publishes the actor's change feed to /events subscribers.
+var eventBroker *events.Broker
+
+// eventsKeepAlive is how often an idle /events stream sends a comment to keep proxies from closing it.
+var eventsKeepAlive = 15 * time.Second
+
+// shareStore tells the change feeds which shared lists a user can see.
+var shareStore *auth.ShareStore
+
+// InitActor initializes the actor instance with the store of shared lists.
+func InitActor(ctx context.Context, shares *auth.ShareStore) {
+	a := actor.NewSharedActor(ctx, shares)
+	actorInstance = a
+	eventBroker = a.Events()
+	shareStore = shares
+}
+
+// route is a pattern served by AddRoutes with the methods it accepts; every route must be described
+// in the OpenAPI document with the same methods.
+type route struct {
+	pattern string
+	methods []string
+	handler http.Handler
+}
+
+// methods shared by several routes
+var (
+	methodsGet     = []string{http.MethodGet}
+	methodsPost    = []string{http.MethodPost}
+	methodsPut     = []string{http.MethodPut}
+	methodsGetPost = []string{http.MethodGet, http.MethodPost}
+	methodsDelete  = []string{http.MethodDelete}
+)
+
+// routes returns the routes of the server in registration order.
+func routes() []route {
+	return []route{
+		{"/create", methodsPost, http.HandlerFunc(createItemHandler)},
+		{"/update", methodsPut, http.HandlerFunc(updateItemHandler)},
+		{"/delete/{itemid}", methodsDelete, http.HandlerFunc(deleteItemHandler)},
+		{"/restore", methodsPost, http.HandlerFunc(restoreItemHandler)},
+		{"/get/{itemid}", methodsGet, http.HandlerFunc(getByIDHandler)},
+		{"/get", methodsGet, http.HandlerFunc(getListHandler)},
+		{"/list", methodsGet, http.HandlerFunc(dynamicListHandler)},
+		{"/list/create", methodsPost, http.HandlerFunc(uiCreateHandler)},
+		{"/list/update/{itemid}", methodsPost, http.HandlerFunc(uiUpdateHandler)},
+		{"/list/delete/{itemid}", methodsGetPost, http.HandlerFunc(uiDeleteHandler)},
+		{"/shares", methodsGetPost, http.HandlerFunc(sharesHandler)},
+		{"/shares/{user}", methodsDelete, http.HandlerFunc(shareByUserHandler)},
+		{"/login", methodsGetPost, http.HandlerFunc(loginHandler)},
+		{"/logout", methodsPost, http.HandlerFunc(logoutHandler)},
+		{"/events", methodsGet, http.HandlerFunc(eventsHandler)},
+		{"/ws", methodsGet, http.HandlerFunc(wsHandler)},
+		{"/webhooks", methodsGetPost, requireAdmin(webhooksHandler)},
+		{"/webhooks/log", methodsGet, requireAdmin(webhookLogHandler)},
+		{"/webhooks/{id}", methodsDelete, requireAdmin(webhookByIDHandler)},
+		{"/metrics", methodsGet, requireMetrics(metricsHandler)},
+		{"/healthz", methodsGet, http.HandlerFunc(healthzHandler)},
+		{"/readyz", methodsGet, http.HandlerFunc(readyzHandler)},
+		{"/openapi.json", methodsGet, http.HandlerFunc(openAPIHandler)},
+		{"/docs", methodsGet, http.HandlerFunc(docsHandler)},
+
+		{"/static/", methodsGet, assetHandler("/static/", "static")},
+		{"/about/", methodsGet, assetHandler("/about/", "static/about")},
+		{"/about", methodsGet, http.RedirectHandler("/about/", http.StatusMovedPermanently)},
+	}
 }
 
 // AddRoutes adds HTTP routes to the provided ServeMux.
 func AddRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/create", createItemHandler)
-	mux.HandleFunc("/update", updateItemHandler)
-	mux.HandleFunc("/delete", deleteItemHandler)
-	mux.HandleFunc("/get/{itemid}", getByIDHandler)
-	mux.HandleFunc("/get", getListHandler)
-	mux.HandleFunc("/list", dynamicListHandler)
-
-	mux.Handle("/about/", http.StripPrefix("/about/", http.FileServer(http.Dir("static/about"))))
-	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
-		http.Redirect(w, r, "/about/", http.StatusMovedPermanently)
+	for _, route := range routes() {
+		mux.Handle(route.pattern, allowMethods(route.methods, route.handler))
+	}
+}
+
+// allowMethods answers 405 to methods a route does not accept, before its handler runs.
+// HEAD is accepted wherever GET is.
+func allowMethods(methods []string, next http.Handler) http.Handler {
+	allowed := slices.Clone(methods)
+	if slices.Contains(allowed, http.MethodGet) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	allow := strings.Join(allowed, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(allowed, r.Method) {
+			w.Header().Set("Allow", allow)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
 
@@ -48,9 +139,9 @@ func getListHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
 		return
 	}
-	items, err := actorInstance.ListAll(context.Background())
+	items, err := actorInstance.ListAll(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		actorError(w, err, http.StatusInternalServerError)
 		return
 	}
 	todos := make([]storage.Item, 0, len(items))
@@ -60,6 +151,7 @@ func getListHandler(w http.ResponseWriter, r *http.Request) {
 			Description: v.Description,
 			Status:      v.Status,
 			Created:     v.Created,
+			Owner:       v.Owner,
 		})
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -82,9 +174,9 @@ func getByIDHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid item ID", http.StatusBadRequest)
 		return
 	}
-	item, err := actorInstance.List(context.Background(), id)
+	item, err := actorInstance.List(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		actorError(w, err, http.StatusNotFound)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -93,6 +185,7 @@ func getByIDHandler(w http.ResponseWriter, r *http.Request) {
 		Description: item.Description,
 		Status:      item.Status,
 		Created:     item.Created,
+		Owner:       item.Owner,
 	})
 }
 
@@ -103,14 +196,13 @@ func createItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var todo storage.Item
-	err := json.NewDecoder(r.Body).Decode(&todo)
-	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &todo) {
 		return
 	}
-	item, err := actorInstance.Create(context.Background(), todo.Description, todo.Status)
+	// the optional owner creates the item in a list shared with the caller
+	item, err := actorInstance.CreateIn(r.Context(), todo.Owner, todo.Description, todo.Status)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		actorError(w, err, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -119,6 +211,7 @@ func createItemHandler(w http.ResponseWriter, r *http.Request) {
 		Description: item.Description,
 		Status:      item.Status,
 		Created:     item.Created,
+		Owner:       item.Owner,
 	})
 }
 
@@ -129,14 +222,12 @@ func updateItemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var todo storage.Item
-	err := json.NewDecoder(r.Body).Decode(&todo)
-	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &todo) {
 		return
 	}
-	item, err := actorInstance.Update(context.Background(), todo.ID, todo.Description, todo.Status)
+	item, err := actorInstance.Update(r.Context(), todo.ID, todo.Description, todo.Status)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		actorError(w, err, http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -145,6 +236,7 @@ func updateItemHandler(w http.ResponseWriter, r *http.Request) {
 		Description: item.Description,
 		Status:      item.Status,
 		Created:     item.Created,
+		Owner:       item.Owner,
 	})
 }
 
@@ -164,24 +256,150 @@ func deleteItemHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid item ID", http.StatusBadRequest)
 		return
 	}
-	err = actorInstance.Delete(context.Background(), id)
+	err = actorInstance.Delete(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		actorError(w, err, http.StatusNotFound)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{"deleted": id})
 }
 
-// dynamicListHandler handles requests to retrieve all todo items.
-func dynamicListHandler(w http.ResponseWriter, r *http.Request) {
-	const listTemplate = "<!doctype html><html><head><meta charset=\"utf-8\"><title>Todos</title><style>body{font-family:Arial,sans-serif;margin:2em;background:#f9f9f9;}h1{color: #007acc;}p{max-width:600px;}ul{display:table;border-collapse:collapse;width:100%;padding:0;margin:0;}ul li{display:table-row;}ul li span{display:table-cell;border:1px solid #007acc;padding:8px;text-align:left;}ul li.header span{font-weight:bold;background-color: #007acc;color: #ffffff;}</style></head><body><h1>Todos</h1><ul><li class='header'><span>ID</span><span>Description</span><span>Status</span></li>{{range .Items}}<li><span>{{.ID}}</span><span>{{.Description}}</span><span>{{.Status}}</span></li>{{else}}<li><span colspan=\"3\">none</span></li>{{end}}</ul></body></html>"
-	list, err := actorInstance.ListAll(context.Background())
+// restoreItemHandler handles requests to put a deleted item back under its ID.
+func restoreItemHandler(w http.ResponseWriter, r *http.Request) {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	var todo storage.Item
+	if !decodeJSON(w, r, &todo) {
+		return
+	}
+	item, err := actorInstance.Restore(r.Context(), todo)
+	if err != nil {
+		actorError(w, err, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(storage.Item{
+		ID:          item.ID,
+		Description: item.Description,
+		Status:      item.Status,
+		Created:     item.Created,
+		Owner:       item.Owner,
+	})
+}
+
+// actorError reports an error returned by the actor.
+// Operations the caller's role does not allow get 403 with a JSON error body, invalid input gets 400, an item ID in use gets 409,
+// commands that timed out or arrived during shutdown get 503 with a JSON error body, and other errors use the given status.
+func actorError(w http.ResponseWriter, err error, status int) {
+	switch {
+	case errors.Is(err, actor.ErrForbidden):
+		writeError(w, http.StatusForbidden, "forbidden", err.Error())
+		return
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		// the command was not applied, so the client can safely retry
+		writeError(w, http.StatusServiceUnavailable, "timeout", "request timed out before it was processed")
+		return
+	case errors.Is(err, actor.ErrStopped):
+		writeError(w, http.StatusServiceUnavailable, "shutting_down", "server is shutting down")
+		return
+	case errors.Is(err, storage.ErrEmptyDescription), errors.Is(err, storage.ErrLongDescription),
+		errors.Is(err, storage.ErrInvalidStatus), errors.Is(err, storage.ErrInvalidID):
+		status = http.StatusBadRequest
+	case errors.Is(err, storage.ErrItemExists):
+		status = http.StatusConflict
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// writeError sends a JSON error body.
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorBody{Error: message, Code: code})
+}
+
+// eventsHandler streams item change events to the client as Server-Sent Events.
+// Clients reconnecting with a Last-Event-ID header receive the events they missed from the in-memory buffer,
+// or a reset event when the buffer no longer covers the gap or the ID is from before a restart,
+// and the full list must be refetched.
+func eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if eventBroker == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// resume position from the EventSource reconnect header, unless it was sent before a restart
+	var lastID uint64
+	sameEpoch := true
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, ok, err := eventBroker.ParseStreamID(header)
+		if err != nil {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastID, sameEpoch = id, ok
+	}
+
+	backlog, ch, complete := eventBroker.Subscribe(lastID)
+	defer eventBroker.Unsubscribe(ch)
+
+	// the stream outlives the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// tell the client it missed events it cannot replay
+	if !complete || !sameEpoch {
+		fmt.Fprintf(w, "id: %s\nevent: reset\ndata: {}\n\n", eventBroker.StreamID(eventBroker.LastID()))
+	}
+	for _, event := range backlog {
+		if canSee(r, event.Item) {
+			writeEvent(w, event)
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-streamsDone:
+			// server shutting down, the client reconnects with Last-Event-ID
+			return
+		case event, open := <-ch:
+			if !open {
+				// dropped as a slow consumer, the client reconnects with Last-Event-ID
+				return
+			}
+			if canSee(r, event.Item) {
+				writeEvent(w, event)
+				flusher.Flush()
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single event in the Server-Sent Events wire format.
+func writeEvent(w http.ResponseWriter, event events.Event) {
+	data, err := json.Marshal(event)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	tpl := template.Must(template.New("list").Parse(listTemplate))
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	_ = tpl.Execute(w, struct{ Items storage.Items }{Items: list})
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", eventBroker.StreamID(event.ID), event.Type, data)
 }
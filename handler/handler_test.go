@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,16 +9,26 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+	"todo-app/actor"
+	"todo-app/auth"
+	"todo-app/events"
 	"todo-app/storage"
 )
 
 // mockActor implements actor interface for testing.
 type mockActor struct {
-	items map[int]storage.Item
+	items  map[int]storage.Item
+	shares []auth.Share
+	deny   error
+	health storage.Health
 }
 
 // ListAll returns all items.
 func (m *mockActor) ListAll(ctx context.Context) (storage.Items, error) {
+	if m.deny != nil {
+		return nil, m.deny
+	}
 	result := make(storage.Items)
 	for k, v := range m.items {
 		result[k] = v
@@ -42,6 +53,55 @@ func (m *mockActor) Create(ctx context.Context, desc, status string) (storage.It
 	return item, nil
 }
 
+// CreateIn creates a new item in the given list.
+func (m *mockActor) CreateIn(ctx context.Context, list, desc, status string) (storage.Item, error) {
+	if m.deny != nil {
+		return storage.Item{}, m.deny
+	}
+	item, _ := m.Create(ctx, desc, status)
+	item.Owner = list
+	m.items[item.ID] = item
+	return item, nil
+}
+
+// Shares returns the shares of a list.
+func (m *mockActor) Shares(ctx context.Context, list string) ([]auth.Share, error) {
+	if m.deny != nil {
+		return nil, m.deny
+	}
+	shares := []auth.Share{}
+	for _, share := range m.shares {
+		if share.List == list {
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+// Share adds a share.
+func (m *mockActor) Share(ctx context.Context, list, user string, role auth.Role) (auth.Share, error) {
+	if m.deny != nil {
+		return auth.Share{}, m.deny
+	}
+	share := auth.Share{List: list, User: user, Role: role}
+	m.shares = append(m.shares, share)
+	return share, nil
+}
+
+// Unshare removes a share.
+func (m *mockActor) Unshare(ctx context.Context, list, user string) error {
+	if m.deny != nil {
+		return m.deny
+	}
+	for i, share := range m.shares {
+		if share.List == list && share.User == user {
+			m.shares = append(m.shares[:i], m.shares[i+1:]...)
+			return nil
+		}
+	}
+	return auth.ErrShareNotFound
+}
+
 // Update updates an existing item.
 func (m *mockActor) Update(ctx context.Context, id int, desc, status string) (storage.Item, error) {
 	item, ok := m.items[id]
@@ -63,6 +123,25 @@ func (m *mockActor) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// Restore puts an item back unless its ID is in use.
+func (m *mockActor) Restore(ctx context.Context, item storage.Item) (storage.Item, error) {
+	if _, ok := m.items[item.ID]; ok {
+		return storage.Item{}, storage.ErrItemExists
+	}
+	m.items[item.ID] = item
+	return item, nil
+}
+
+// Ping answers unless the mock is set to fail.
+func (m *mockActor) Ping(ctx context.Context) error {
+	return m.deny
+}
+
+// CheckStorage reports the mock's storage health.
+func (m *mockActor) CheckStorage(ctx context.Context) (storage.Health, error) {
+	return m.health, m.deny
+}
+
 // setupMockActor initializes the mock actor for testing.
 func setupMockActor() {
 	mock := &mockActor{items: map[int]storage.Item{
@@ -137,6 +216,30 @@ func TestHandler_CreateItemHandler(t *testing.T) {
 	}
 }
 
+// TestHandler_CreateItemHandler_Timeout tests that a command the actor gave up on is reported as retryable.
+func TestHandler_CreateItemHandler_Timeout(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code string
+	}{
+		{context.DeadlineExceeded, "timeout"},
+		{context.Canceled, "timeout"},
+		{actor.ErrStopped, "shutting_down"},
+	} {
+		actorInstance = &mockActor{items: map[int]storage.Item{}, deny: tc.err}
+		req := httptest.NewRequest("POST", "/create", strings.NewReader(`{"Description":"Late","Status":"not_started"}`))
+		w := httptest.NewRecorder()
+		createItemHandler(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%v: expected 503, got %d", tc.err, w.Code)
+		}
+		var body errorBody
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Code != tc.code {
+			t.Errorf("%v: expected code %q, got %+v (%v)", tc.err, tc.code, body, err)
+		}
+	}
+}
+
 // TestHandler_UpdateItemHandler tests the updateItemHandler function.
 func TestHandler_UpdateItemHandler(t *testing.T) {
 	setupMockActor()
@@ -174,6 +277,30 @@ func TestHandler_DeleteItemHandler(t *testing.T) {
 	}
 }
 
+// TestHandler_RestoreItemHandler tests that a deleted item comes back under its ID and an ID in use is a conflict.
+func TestHandler_RestoreItemHandler(t *testing.T) {
+	setupMockActor()
+	body := `{"id": 7, "description": "Restored", "status": "in_progress", "created": "2026-01-02T03:04:05Z", "owner": "alice"}`
+	w := httptest.NewRecorder()
+	restoreItemHandler(w, httptest.NewRequest("POST", "/restore", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	var item storage.Item
+	if err := json.NewDecoder(w.Body).Decode(&item); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if item.ID != 7 || item.Owner != "alice" || item.Created.Year() != 2026 {
+		t.Errorf("unexpected item: %+v", item)
+	}
+
+	w = httptest.NewRecorder()
+	restoreItemHandler(w, httptest.NewRequest("POST", "/restore", strings.NewReader(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 restoring twice, got %d", w.Code)
+	}
+}
+
 // TestHandler_DeleteItemHandler_NotFound tests deleteItemHandler for non-existent ID.
 func TestHandler_DeleteItemHandler_NotFound(t *testing.T) {
 	setupMockActor()
@@ -185,6 +312,37 @@ func TestHandler_DeleteItemHandler_NotFound(t *testing.T) {
 	}
 }
 
+// TestHandler_Routes_MethodNotAllowed tests that the routes refuse methods they do not describe,
+// so a cross-site link cannot delete an item with GET.
+func TestHandler_Routes_MethodNotAllowed(t *testing.T) {
+	setupMockActor()
+	req := httptest.NewRequest("GET", "/delete/1", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w := serveWrapped(t, req)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "DELETE" {
+		t.Fatalf("expected 405 allowing DELETE, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	if _, err := actorInstance.List(context.Background(), 1); err != nil {
+		t.Errorf("expected item 1 to survive the GET: %v", err)
+	}
+
+	for _, tt := range []struct{ method, target, allow string }{
+		{"GET", "/create", "POST"},
+		{"POST", "/update", "PUT"},
+		{"PUT", "/get/1", "GET, HEAD"},
+		{"POST", "/healthz", "GET, HEAD"},
+	} {
+		w := serveWrapped(t, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: expected 405 allowing %s, got %d %q", tt.method, tt.target, tt.allow, w.Code, w.Header().Get("Allow"))
+		}
+	}
+
+	if w := serveWrapped(t, httptest.NewRequest("DELETE", "/delete/1", nil)); w.Code != http.StatusOK {
+		t.Errorf("expected DELETE /delete/1 to succeed, got %d %s", w.Code, w.Body.String())
+	}
+}
+
 // TestHandler_DynamicListHandler tests the dynamicListHandler function.
 func TestHandler_DynamicListHandler(t *testing.T) {
 	setupMockActor()
@@ -411,3 +569,125 @@ func TestHandler_Concurrency_DeleteAndRead(t *testing.T) {
 		<-done
 	}
 }
+
+// TestHandler_EventsHandler_Resume tests that /events replays buffered events after Last-Event-ID.
+func TestHandler_EventsHandler_Resume(t *testing.T) {
+	eventBroker = events.NewBroker(10)
+	eventBroker.Publish(events.ItemCreated, storage.Item{ID: 1, Description: "First"})
+	eventBroker.Publish(events.ItemUpdated, storage.Item{ID: 1, Description: "Second"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", eventBroker.StreamID(1))
+	w := httptest.NewRecorder()
+	eventsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "id: "+eventBroker.StreamID(1)+"\n") {
+		t.Errorf("event 1 should not be replayed: %s", body)
+	}
+	if strings.Contains(body, "event: reset\n") {
+		t.Errorf("expected no reset within the same epoch: %s", body)
+	}
+	if !strings.Contains(body, "id: "+eventBroker.StreamID(2)+"\nevent: item.updated\n") || !strings.Contains(body, "Second") {
+		t.Errorf("expected event 2 to be replayed: %s", body)
+	}
+}
+
+// TestHandler_EventsHandler_Reset tests that /events sends a reset when the buffer cannot cover the gap.
+func TestHandler_EventsHandler_Reset(t *testing.T) {
+	eventBroker = events.NewBroker(1)
+	eventBroker.Publish(events.ItemCreated, storage.Item{ID: 1})
+	eventBroker.Publish(events.ItemCreated, storage.Item{ID: 2})
+	eventBroker.Publish(events.ItemCreated, storage.Item{ID: 3})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", eventBroker.StreamID(1))
+	w := httptest.NewRecorder()
+	eventsHandler(w, req)
+
+	if !strings.Contains(w.Body.String(), "event: reset\n") {
+		t.Errorf("expected reset event: %s", w.Body.String())
+	}
+}
+
+// TestHandler_EventsHandler_Restart tests that a Last-Event-ID from before a restart gets a reset
+// instead of the events that happen to reuse its number.
+func TestHandler_EventsHandler_Restart(t *testing.T) {
+	before := events.NewBroker(10)
+	before.Publish(events.ItemCreated, storage.Item{ID: 1, Description: "Old"})
+	eventBroker = events.NewBroker(10)
+	eventBroker.Publish(events.ItemCreated, storage.Item{ID: 1, Description: "First"})
+	eventBroker.Publish(events.ItemUpdated, storage.Item{ID: 1, Description: "Second"})
+
+	for _, lastEventID := range []string{before.StreamID(1), "1"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		w := httptest.NewRecorder()
+		eventsHandler(w, req)
+		cancel()
+
+		body := w.Body.String()
+		if !strings.HasPrefix(body, "id: "+eventBroker.StreamID(2)+"\nevent: reset\n") {
+			t.Errorf("Last-Event-ID %s: expected a reset first: %s", lastEventID, body)
+		}
+		if strings.Contains(body, "Second") {
+			t.Errorf("Last-Event-ID %s: expected no replay across a restart: %s", lastEventID, body)
+		}
+	}
+}
+
+// TestHandler_EventsHandler_InvalidLastEventID tests /events with a malformed Last-Event-ID.
+func TestHandler_EventsHandler_InvalidLastEventID(t *testing.T) {
+	eventBroker = events.NewBroker(10)
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+	eventsHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+// TestHandler_EventsHandler_Live tests that /events streams events published after connecting.
+func TestHandler_EventsHandler_Live(t *testing.T) {
+	eventBroker = events.NewBroker(10)
+	server := httptest.NewServer(http.HandlerFunc(eventsHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	eventBroker.Publish(events.ItemCreated, storage.Item{ID: 5, Description: "Live"})
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			var event events.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if event.Item.Description != "Live" {
+				t.Errorf("unexpected event: %+v", event)
+			}
+			return
+		}
+	}
+}
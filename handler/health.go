@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+	"todo-app/storage"
+)
+
+// healthTimeout bounds how long a probe waits for the actor before reporting it as stuck.
+const healthTimeout = 2 * time.Second
+
+const (
+	checkOK   string = "ok"
+	checkFail string = "fail"
+)
+
+// healthCheck is the result of one check in a /healthz or /readyz response.
+type healthCheck struct {
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration_ms,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Time     string  `json:"time,omitempty"`
+}
+
+// healthReport is the JSON body of /healthz and /readyz.
+type healthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+// healthzHandler reports whether the process is alive and the actor goroutine answers a ping.
+// It returns 503 when the actor is stuck, so a supervisor can restart the server.
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireProbe(w, r) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	started := time.Now()
+	check := healthCheck{Status: checkOK}
+	if err := actorInstance.Ping(ctx); err != nil {
+		slog.ErrorContext(r.Context(), "Health check failed", "check", "actor", "error", err)
+		check = healthCheck{Status: checkFail, Error: probeError(err)}
+	}
+	check.Duration = milliseconds(time.Since(started))
+	writeHealth(w, map[string]healthCheck{"actor": check})
+}
+
+// readyzHandler reports whether the server can serve requests: the actor can take the storage lock,
+// the data file can be read and written, and the last save succeeded.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireProbe(w, r) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+
+	started := time.Now()
+	health, err := actorInstance.CheckStorage(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Readiness check failed", "check", "storage_lock", "error", err)
+		failed := healthCheck{Status: checkFail, Error: "storage check did not run: " + probeError(err)}
+		writeHealth(w, map[string]healthCheck{"storage_lock": failed, "data_file": failed, "last_save": failed})
+		return
+	}
+	checks := map[string]healthCheck{
+		"storage_lock": {Status: checkOK, Duration: milliseconds(time.Since(started))},
+		"data_file":    {Status: checkOK},
+		"last_save":    {Status: checkOK},
+	}
+	if health.File != nil {
+		slog.ErrorContext(r.Context(), "Readiness check failed", "check", "data_file", "error", health.File)
+		checks["data_file"] = healthCheck{Status: checkFail, Error: fileError(health.File)}
+	}
+	lastSave := checks["last_save"]
+	if !health.LastSave.IsZero() {
+		lastSave.Time = health.LastSave.Format(time.RFC3339)
+	}
+	if health.LastSaveErr != nil {
+		slog.ErrorContext(r.Context(), "Readiness check failed", "check", "last_save", "error", health.LastSaveErr)
+		lastSave.Status, lastSave.Error = checkFail, "last save failed"
+	}
+	checks["last_save"] = lastSave
+	writeHealth(w, checks)
+}
+
+// requireProbe rejects anything but GET and HEAD, and reports an actor that was never started.
+func requireProbe(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if actorInstance == nil {
+		writeHealth(w, map[string]healthCheck{"actor": {Status: checkFail, Error: "actor not initialized"}})
+		return false
+	}
+	return true
+}
+
+// writeHealth sends the report, with 503 when any check failed.
+func writeHealth(w http.ResponseWriter, checks map[string]healthCheck) {
+	report := healthReport{Status: checkOK, Checks: checks}
+	status := http.StatusOK
+	for _, check := range checks {
+		if check.Status != checkOK {
+			report.Status, status = checkFail, http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// probeError describes why the actor did not answer, without internal details.
+func probeError(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "actor did not respond within " + healthTimeout.String()
+	case errors.Is(err, context.Canceled):
+		return "probe cancelled"
+	default:
+		return err.Error()
+	}
+}
+
+// fileError describes a data file problem without revealing its path, which is only logged.
+func fileError(err error) string {
+	for _, known := range []error{storage.ErrDataFileNotOpen, storage.ErrDataFileNotReadable, storage.ErrDataFileNotWritable} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return "data file check failed"
+}
+
+// milliseconds converts a duration to fractional milliseconds for the JSON report.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
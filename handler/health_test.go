@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/storage"
+)
+
+// getHealth calls a probe handler and decodes its report.
+func getHealth(t *testing.T, handler http.HandlerFunc, path string) (int, healthReport) {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", path, nil))
+	var report healthReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	return w.Code, report
+}
+
+// TestHandler_Healthz tests that /healthz reports a responsive actor.
+func TestHandler_Healthz(t *testing.T) {
+	setupMockActor()
+	code, report := getHealth(t, healthzHandler, "/healthz")
+	if code != http.StatusOK || report.Status != "ok" || report.Checks["actor"].Status != "ok" {
+		t.Errorf("expected a healthy report, got %d %+v", code, report)
+	}
+}
+
+// TestHandler_Healthz_StuckActor tests that /healthz fails when the actor does not answer in time.
+func TestHandler_Healthz_StuckActor(t *testing.T) {
+	actorInstance = &mockActor{deny: context.DeadlineExceeded}
+	code, report := getHealth(t, healthzHandler, "/healthz")
+	if code != http.StatusServiceUnavailable || report.Status != "fail" {
+		t.Fatalf("expected 503 fail, got %d %+v", code, report)
+	}
+	if !strings.Contains(report.Checks["actor"].Error, "did not respond") {
+		t.Errorf("unexpected error: %q", report.Checks["actor"].Error)
+	}
+}
+
+// TestHandler_Readyz tests the storage checks reported by /readyz.
+func TestHandler_Readyz(t *testing.T) {
+	saved := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		mock   *mockActor
+		code   int
+		failed []string
+	}{
+		{"ready", &mockActor{health: storage.Health{LastSave: saved}}, http.StatusOK, nil},
+		{"file", &mockActor{health: storage.Health{File: fmt.Errorf("%w: open /secret/path: permission denied", storage.ErrDataFileNotWritable)}}, http.StatusServiceUnavailable, []string{"data_file"}},
+		{"save", &mockActor{health: storage.Health{LastSave: saved, LastSaveErr: errors.New("disk full")}}, http.StatusServiceUnavailable, []string{"last_save"}},
+		{"stuck", &mockActor{deny: context.DeadlineExceeded}, http.StatusServiceUnavailable, []string{"storage_lock", "data_file", "last_save"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actorInstance = tc.mock
+			code, report := getHealth(t, readyzHandler, "/readyz")
+			if code != tc.code {
+				t.Errorf("expected %d, got %d", tc.code, code)
+			}
+			for name, check := range report.Checks {
+				failed := false
+				for _, f := range tc.failed {
+					failed = failed || f == name
+				}
+				if (check.Status == "fail") != failed {
+					t.Errorf("unexpected %s check: %+v", name, check)
+				}
+				if strings.Contains(check.Error, "/secret/path") {
+					t.Errorf("file path leaked in %s check: %q", name, check.Error)
+				}
+			}
+			if tc.name == "ready" && report.Checks["last_save"].Time != "2026-10-01T12:00:00Z" {
+				t.Errorf("expected the last save time, got %+v", report.Checks["last_save"])
+			}
+		})
+	}
+}
+
+// TestHandler_Health_Public tests that probes need no token.
+func TestHandler_Health_Public(t *testing.T) {
+	setupMockActor()
+	for _, path := range []string{"/healthz", "/readyz"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer not-a-token")
+		if w := serveWrapped(t, req); w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 without a valid token, got %d", path, w.Code)
+		}
+	}
+}
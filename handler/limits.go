@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"todo-app/auth"
+	"todo-app/ratelimit"
+)
+
+// Limits are the request thresholds enforced by the server middleware.
+type Limits struct {
+	// RequestsPerSecond is the sustained request rate allowed per client; zero disables rate limiting.
+	RequestsPerSecond float64
+	// Burst is how many requests a client may make at once before being limited.
+	Burst int
+	// MaxBodyBytes is the largest request body accepted.
+	MaxBodyBytes int64
+}
+
+// DefaultLimits are the thresholds used unless configured otherwise.
+var DefaultLimits = Limits{RequestsPerSecond: 10, Burst: 40, MaxBodyBytes: 1 << 20}
+
+// clientLimiter throttles each client IP and userLimiter each user; requests are not throttled until InitLimits is called.
+var clientLimiter, userLimiter *ratelimit.Limiter
+
+// maxBodyBytes caps the size of every request body.
+var maxBodyBytes = DefaultLimits.MaxBodyBytes
+
+// InitLimits sets the rate limit and request size thresholds.
+func InitLimits(limits Limits) {
+	clientLimiter, userLimiter = nil, nil
+	if limits.RequestsPerSecond > 0 {
+		clientLimiter = ratelimit.NewLimiter(limits.RequestsPerSecond, limits.Burst)
+		userLimiter = ratelimit.NewLimiter(limits.RequestsPerSecond, limits.Burst)
+	}
+	maxBodyBytes = limits.MaxBodyBytes
+}
+
+// limitClients throttles requests per client IP. It runs before authenticate,
+// so requests with a wrong token or session are charged too and cannot be used to guess tokens.
+func limitClients(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clientLimiter != nil && !allowRequest(w, clientLimiter, "ip:"+clientIP(r)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimit throttles authenticated requests per user, across every address the user connects from.
+// It runs after authenticate so callers cannot dodge their limit by sending made-up tokens.
+func rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.FromContext(r.Context())
+		if ok && userLimiter != nil && !allowRequest(w, userLimiter, "user:"+identity.User) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowRequest takes a token for the key, or answers 429 with Retry-After when its bucket is empty.
+func allowRequest(w http.ResponseWriter, limiter *ratelimit.Limiter, key string) bool {
+	if ok, wait := limiter.Allow(key); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "rate_limited", "Too many requests, retry later")
+		return false
+	}
+	return true
+}
+
+// limitBody caps the size of request bodies; reading past the limit fails.
+func limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeJSON reads a JSON request body into v.
+// It answers 413 when the body is over the size limit and 400 when it is not valid JSON.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// clientIP returns the address of the directly connected client; proxy headers are not trusted.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
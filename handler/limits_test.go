@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/auth"
+	"todo-app/storage"
+)
+
+// resetLimits turns rate limiting off again after a test.
+func resetLimits() {
+	InitLimits(Limits{MaxBodyBytes: DefaultLimits.MaxBodyBytes})
+}
+
+// TestHandler_Limits_RateLimited tests that clients over their rate get 429 with Retry-After.
+func TestHandler_Limits_RateLimited(t *testing.T) {
+	InitLimits(Limits{RequestsPerSecond: 1, Burst: 2, MaxBodyBytes: 1024})
+	defer resetLimits()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	alice := auth.WithIdentity(context.Background(), auth.Identity{User: "alice"})
+	send := func(ctx context.Context, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/get", nil).WithContext(ctx)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		rateLimit(ok).ServeHTTP(w, req)
+		return w
+	}
+
+	// the limit follows the user across addresses
+	send(alice, "10.0.0.1:1000")
+	send(alice, "10.0.0.2:1000")
+	w := send(alice, "10.0.0.3:1000")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After 1, got %q", w.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(w.Body.String(), `"code":"rate_limited"`) {
+		t.Errorf("expected JSON error body, got %s", w.Body.String())
+	}
+
+	// anonymous requests are left to the per-address limit in front of authenticate
+	if w := send(context.Background(), "10.0.0.1:1000"); w.Code != http.StatusOK {
+		t.Errorf("expected anonymous client not to be limited per user, got %d", w.Code)
+	}
+}
+
+// TestHandler_Limits_FailedAuthCharged tests that requests with wrong tokens use up the client's address limit,
+// so tokens cannot be guessed at an unlimited rate.
+func TestHandler_Limits_FailedAuthCharged(t *testing.T) {
+	setupMockActor()
+	token := setupAuth(t, "tester")
+	InitLimits(Limits{RequestsPerSecond: 1, Burst: 2, MaxBodyBytes: 1024})
+	defer resetLimits()
+
+	send := func(remoteAddr string, secret string) int {
+		req := httptest.NewRequest("GET", "/get", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+secret)
+		return serveWrapped(t, req).Code
+	}
+	for i := range 2 {
+		if code := send("10.0.0.1:1000", fmt.Sprintf("guess-%d", i)); code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: expected 401, got %d", i, code)
+		}
+	}
+	if code := send("10.0.0.1:1000", "guess-2"); code != http.StatusTooManyRequests {
+		t.Errorf("expected guesses over the limit to get 429, got %d", code)
+	}
+	if code := send("10.0.0.1:1000", token); code != http.StatusTooManyRequests {
+		t.Errorf("expected the address to stay limited for a valid token too, got %d", code)
+	}
+	if code := send("10.0.0.2:1000", token); code != http.StatusOK {
+		t.Errorf("expected another address to have its own limit, got %d", code)
+	}
+}
+
+// TestHandler_Limits_BodyTooLarge tests that oversized JSON bodies are rejected with 413.
+func TestHandler_Limits_BodyTooLarge(t *testing.T) {
+	setupMockActor()
+	InitLimits(Limits{MaxBodyBytes: 64})
+	defer resetLimits()
+
+	body := `{"description":"` + strings.Repeat("x", 100) + `","status":"not_started"}`
+	w := serveWrapped(t, httptest.NewRequest("POST", "/create", strings.NewReader(body)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", w.Code)
+	}
+	if len(actorInstance.(*mockActor).items) != 1 {
+		t.Error("expected no item to be created")
+	}
+}
+
+// TestHandler_Limits_InvalidInput tests that validation errors from storage are reported as bad requests.
+func TestHandler_Limits_InvalidInput(t *testing.T) {
+	w := httptest.NewRecorder()
+	actorError(w, fmt.Errorf("%w: at most 5 characters", storage.ErrLongDescription), http.StatusInternalServerError)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// loginPage is the data rendered by the login template.
+type loginPage struct {
+	User      string
+	Next      string
+	Error     string
+	CSRFToken string
+}
+
+// loginHandler shows the login form (GET) and starts a session for valid credentials (POST).
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		renderPage(w, "login", loginPage{Next: safeNext(r.URL.Query().Get("next")), CSRFToken: csrfToken(r)})
+	case http.MethodPost:
+		if userStore == nil {
+			http.Error(w, "Authentication not initialized", http.StatusInternalServerError)
+			return
+		}
+		name := strings.TrimSpace(r.PostFormValue("user"))
+		next := safeNext(r.PostFormValue("next"))
+		identity, ok := userStore.Verify(name, r.PostFormValue("password"))
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			renderPage(w, "login", loginPage{User: name, Next: next, Error: "Invalid user name or password", CSRFToken: csrfToken(r)})
+			return
+		}
+
+		// never carry a session ID over a login, so a planted cookie cannot be fixed to this user
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			sessionStore.Delete(cookie.Value)
+		}
+		setSessionCookie(w, r, sessionStore.Create(identity))
+		http.Redirect(w, r, next, http.StatusSeeOther)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// logoutHandler ends the session and returns to the login page.
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		sessionStore.Delete(cookie.Value)
+	}
+	clearSessionCookie(w, r)
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// safeNext returns the page to go to after login, only allowing paths on this site.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/list"
+	}
+	return next
+}
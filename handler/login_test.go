@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-app/auth"
+	"todo-app/storage"
+)
+
+// recordingActor remembers which user the last list was made for.
+type recordingActor struct {
+	mockActor
+	user string
+}
+
+// ListAll records the caller and returns all items.
+func (a *recordingActor) ListAll(ctx context.Context) (storage.Items, error) {
+	identity, _ := auth.FromContext(ctx)
+	a.user = identity.User
+	return a.mockActor.ListAll(ctx)
+}
+
+// setupLogin installs a user store with one user and returns the routes wrapped in the server middleware.
+func setupLogin(t *testing.T, user string, password string) http.Handler {
+	tokens, err := auth.OpenTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	if err != nil {
+		t.Fatalf("OpenTokenStore failed: %v", err)
+	}
+	users, err := auth.OpenUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatalf("OpenUserStore failed: %v", err)
+	}
+	if _, err := users.Create(user, password, nil); err != nil {
+		t.Fatalf("Create user failed: %v", err)
+	}
+	InitAuth(tokens, users)
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+	return Wrap(mux)
+}
+
+// postLogin submits the login form with a valid CSRF token.
+func postLogin(server http.Handler, user string, password string, next string) *httptest.ResponseRecorder {
+	csrf := strings.Repeat("c", 64)
+	form := url.Values{"user": {user}, "password": {password}, "next": {next}, csrfFieldName: {csrf}}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: csrf})
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+// sessionCookie returns the session cookie set by a response.
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == sessionCookieName {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// TestHandler_Login_RedirectsPages tests that browsers without a session are sent to the login page.
+func TestHandler_Login_RedirectsPages(t *testing.T) {
+	setupMockActor()
+	server := setupLogin(t, "alice", "correct horse")
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/list?status=in_progress", nil))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/login?next=%2Flist%3Fstatus%3Din_progress" {
+		t.Errorf("unexpected redirect: %s", location)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `name="password"`) {
+		t.Errorf("unexpected login page: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestHandler_Login_Session tests that a login session authenticates the UI and reaches the actor through the context.
+func TestHandler_Login_Session(t *testing.T) {
+	recorder := &recordingActor{mockActor: mockActor{items: map[int]storage.Item{}}}
+	actorInstance = recorder
+	server := setupLogin(t, "alice", "correct horse")
+
+	w := postLogin(server, "alice", "correct horse", "/list?status=in_progress")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/list?status=in_progress" {
+		t.Fatalf("unexpected login response: %d %s", w.Code, w.Header().Get("Location"))
+	}
+	cookie := sessionCookie(w)
+	if cookie == nil || !cookie.HttpOnly {
+		t.Fatalf("expected an HttpOnly session cookie, got %+v", cookie)
+	}
+
+	req := httptest.NewRequest("GET", "/list", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Signed in as alice") {
+		t.Fatalf("unexpected list page: %d %s", w.Code, w.Body.String())
+	}
+	if recorder.user != "alice" {
+		t.Errorf("expected actor to be called as alice, got %q", recorder.user)
+	}
+}
+
+// TestHandler_Login_WrongPassword tests that a failed login shows the form again without a session.
+func TestHandler_Login_WrongPassword(t *testing.T) {
+	server := setupLogin(t, "alice", "correct horse")
+
+	w := postLogin(server, "alice", "wrong password", "/list")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if sessionCookie(w) != nil {
+		t.Error("expected no session cookie")
+	}
+	if !strings.Contains(w.Body.String(), "Invalid user name or password") {
+		t.Errorf("expected error message: %s", w.Body.String())
+	}
+}
+
+// TestHandler_Login_Logout tests that logging out ends the session.
+func TestHandler_Login_Logout(t *testing.T) {
+	setupMockActor()
+	server := setupLogin(t, "alice", "correct horse")
+	cookie := sessionCookie(postLogin(server, "alice", "correct horse", "/list"))
+
+	csrf := strings.Repeat("c", 64)
+	req := httptest.NewRequest("POST", "/logout", strings.NewReader(url.Values{csrfFieldName: {csrf}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookie)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: csrf})
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/get", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after logout, got %d", w.Code)
+	}
+}
+
+// TestHandler_Login_SafeNext tests that logins only redirect within the site.
+func TestHandler_Login_SafeNext(t *testing.T) {
+	cases := map[string]string{
+		"/list?status=in_progress": "/list?status=in_progress",
+		"":                         "/list",
+		"https://evil.example":     "/list",
+		"//evil.example":           "/list",
+		"/\\evil.example":          "/list",
+	}
+	for next, want := range cases {
+		if got := safeNext(next); got != want {
+			t.Errorf("safeNext(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-app/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounter("todo_http_requests_total", "HTTP requests served, by route, method and status code.", "route", "method", "status")
+	httpDuration = metrics.NewHistogram("todo_http_request_duration_seconds", "Time taken to serve HTTP requests, by route.", metrics.DefaultBuckets, "route")
+)
+
+// unmatchedRoute labels requests that no route handles, so unknown paths cannot grow the number of series.
+const unmatchedRoute string = "unmatched"
+
+// knownMethods are recorded as they are, anything else is counted as OTHER.
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// measureRequests counts requests and records their latency, labeled with the pattern routes matches them to.
+func measureRequests(routes http.Handler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		route := routePattern(routes, r)
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		method := r.Method
+		if !knownMethods[method] {
+			method = "OTHER"
+		}
+		httpRequests.Inc(route, method, strconv.Itoa(status))
+		httpDuration.Observe(time.Since(started).Seconds(), route)
+	})
+}
+
+// routePattern returns the ServeMux pattern that would handle the request.
+func routePattern(routes http.Handler, r *http.Request) string {
+	if mux, ok := routes.(*http.ServeMux); ok {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			return pattern
+		}
+	}
+	return unmatchedRoute
+}
+
+// metricsHandler serves every metric in the Prometheus text exposition format.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.Default.WriteText(w); err != nil {
+		slog.ErrorContext(r.Context(), "Write metrics failed", "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHandler_Metrics_RequiresScope tests that only admins and metrics tokens can scrape /metrics.
+func TestHandler_Metrics_RequiresScope(t *testing.T) {
+	setupMockActor()
+	w := serveWrapped(t, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+// TestHandler_Metrics_ScraperToken tests that a token with the metrics scope can scrape /metrics
+// but cannot read or change items.
+func TestHandler_Metrics_ScraperToken(t *testing.T) {
+	setupMockActor()
+	token := setupAuth(t, "prometheus", "metrics")
+	send := func(method, target string) int {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return serveWrapped(t, req).Code
+	}
+
+	if code := send("GET", "/metrics"); code != http.StatusOK {
+		t.Errorf("expected the metrics token to scrape, got %d", code)
+	}
+	for _, target := range []string{"/get", "/get/1", "/list", "/webhooks"} {
+		if code := send("GET", target); code != http.StatusForbidden {
+			t.Errorf("GET %s: expected 403 for the metrics token, got %d", target, code)
+		}
+	}
+	if code := send("DELETE", "/delete/1"); code != http.StatusForbidden {
+		t.Errorf("expected the metrics token not to delete, got %d", code)
+	}
+	if _, ok := actorInstance.(*mockActor).items[1]; !ok {
+		t.Error("expected item 1 to survive")
+	}
+}
+
+// TestHandler_Metrics_Exposition tests that served requests show up per route in the Prometheus text format.
+func TestHandler_Metrics_Exposition(t *testing.T) {
+	setupMockActor()
+	serveWrapped(t, httptest.NewRequest("GET", "/get/1", nil))
+	serveWrapped(t, httptest.NewRequest("GET", "/no/such/path", nil))
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+setupAuth(t, "scraper", "admin"))
+	w := serveWrapped(t, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE todo_http_requests_total counter",
+		`todo_http_requests_total{route="/get/{itemid}",method="GET",status="200"}`,
+		`todo_http_requests_total{route="unmatched",method="GET",status="404"}`,
+		`todo_http_request_duration_seconds_bucket{route="/get/{itemid}",le="+Inf"}`,
+		"# TYPE todo_actor_command_duration_seconds histogram",
+		"# TYPE todo_actor_queue_depth gauge",
+		"# TYPE todo_storage_save_duration_seconds histogram",
+		"# TYPE todo_storage_file_size_bytes gauge",
+		"# TYPE todo_items gauge",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics:\n%s", want, body)
+		}
+	}
+}
+
+// TestHandler_Metrics_MethodLabel tests that unusual methods are grouped so clients cannot add series at will.
+func TestHandler_Metrics_MethodLabel(t *testing.T) {
+	setupMockActor()
+	serveWrapped(t, httptest.NewRequest("BREW", "/get", nil))
+
+	w := httptest.NewRecorder()
+	metricsHandler(w, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(w.Body.String(), `method="BREW"`) || !strings.Contains(w.Body.String(), `method="OTHER"`) {
+		t.Errorf("expected BREW to be counted as OTHER:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// serveWrapped sends an authenticated request through the registered routes and the server middleware.
+func serveWrapped(t *testing.T, req *http.Request) *httptest.ResponseRecorder {
+	if req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+setupAuth(t, "tester"))
+	}
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+	w := httptest.NewRecorder()
+	Wrap(mux).ServeHTTP(w, req)
+	return w
+}
+
+// formRequest builds a form POST carrying the given CSRF cookie and field values.
+func formRequest(target string, cookie string, field string) *http.Request {
+	form := url.Values{"description": {"Form item"}, "status": {"not_started"}}
+	if field != "" {
+		form.Set(csrfFieldName, field)
+	}
+	req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: cookie})
+	}
+	return req
+}
+
+// TestHandler_Middleware_SecurityHeaders tests that pages get a strict CSP and hardening headers.
+func TestHandler_Middleware_SecurityHeaders(t *testing.T) {
+	setupMockActor()
+	w := serveWrapped(t, httptest.NewRequest("GET", "/list", nil))
+	csp := w.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "script-src 'self'") || strings.Contains(csp, "unsafe-inline") {
+		t.Errorf("unexpected CSP: %s", csp)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("missing hardening headers: %v", w.Header())
+	}
+	// the page must not rely on inline script or style, which the policy blocks
+	body := w.Body.String()
+	if strings.Contains(body, "<script>") || strings.Contains(body, "<style>") {
+		t.Errorf("page contains inline script or style: %s", body)
+	}
+}
+
+// TestHandler_Middleware_CSRFTokenIssued tests that pages get a token cookie and embed it in their forms.
+func TestHandler_Middleware_CSRFTokenIssued(t *testing.T) {
+	setupMockActor()
+	w := serveWrapped(t, httptest.NewRequest("GET", "/list", nil))
+	var token string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == csrfCookieName {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		t.Fatal("expected CSRF cookie to be set")
+	}
+	if !strings.Contains(w.Body.String(), `name="csrf_token" value="`+token+`"`) {
+		t.Errorf("expected forms to embed the CSRF token")
+	}
+}
+
+// TestHandler_Middleware_CSRFRejectsMissingToken tests that form posts without a valid token are rejected.
+func TestHandler_Middleware_CSRFRejectsMissingToken(t *testing.T) {
+	setupMockActor()
+	token := newCSRFToken()
+
+	cases := map[string]*http.Request{
+		"no cookie":       formRequest("/list/create", "", token),
+		"no field":        formRequest("/list/create", token, ""),
+		"mismatch":        formRequest("/list/create", token, newCSRFToken()),
+		"no token at all": formRequest("/list/create", "", ""),
+	}
+	for name, req := range cases {
+		if w := serveWrapped(t, req); w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", name, w.Code)
+		}
+	}
+	if len(actorInstance.(*mockActor).items) != 1 {
+		t.Error("rejected requests must not create items")
+	}
+}
+
+// TestHandler_Middleware_CSRFAcceptsValidToken tests that form posts with a matching token go through.
+func TestHandler_Middleware_CSRFAcceptsValidToken(t *testing.T) {
+	setupMockActor()
+	token := newCSRFToken()
+	w := serveWrapped(t, formRequest("/list/create", token, token))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+}
+
+// TestHandler_Middleware_CSRFHeader tests that cookie-carrying API requests can send the token as a header.
+func TestHandler_Middleware_CSRFHeader(t *testing.T) {
+	setupMockActor()
+	token := newCSRFToken()
+	req := httptest.NewRequest("POST", "/create", strings.NewReader(`{"description":"Header","status":"not_started"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	if w := serveWrapped(t, req); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without header, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/create", strings.NewReader(`{"description":"Header","status":"not_started"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(csrfHeaderName, token)
+	req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: token})
+	if w := serveWrapped(t, req); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with header, got %d", w.Code)
+	}
+}
+
+// TestHandler_Middleware_JSONAPIWithoutCookies tests that plain API clients do not need a token.
+func TestHandler_Middleware_JSONAPIWithoutCookies(t *testing.T) {
+	setupMockActor()
+	req := httptest.NewRequest("POST", "/create", strings.NewReader(`{"description":"API","status":"not_started"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if w := serveWrapped(t, req); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+// TestHandler_Middleware_CrossSiteRejected tests that browsers' cross-site requests are rejected.
+func TestHandler_Middleware_CrossSiteRejected(t *testing.T) {
+	setupMockActor()
+	req := httptest.NewRequest("DELETE", "/delete/1", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	if w := serveWrapped(t, req); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+// TestHandler_Middleware_WSOrigin tests that WebSocket upgrades from other origins are rejected.
+func TestHandler_Middleware_WSOrigin(t *testing.T) {
+	setupMockActor()
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Origin", "http://evil.example.com")
+	w := httptest.NewRecorder()
+	wsHandler(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// openAPIFile is the OpenAPI document in the asset file system.
+const openAPIFile string = "openapi.json"
+
+// openAPIMethods are the operation keys of an OpenAPI path item, in the order the docs page lists them.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// openAPIDoc is the part of the OpenAPI document rendered by the docs page.
+type openAPIDoc struct {
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	} `json:"info"`
+	Paths map[string]map[string]openAPIOperation `json:"paths"`
+}
+
+// openAPIOperation is one method of a path in the OpenAPI document.
+// Security is only set for operations that override the document's default, such as public routes.
+type openAPIOperation struct {
+	Summary     string                     `json:"summary"`
+	Description string                     `json:"description"`
+	Security    *[]map[string][]string     `json:"security"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+}
+
+// docsOperation is one row of the docs page.
+type docsOperation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Public      bool
+	Responses   []string
+}
+
+// docsPage is the data rendered by the docs template.
+type docsPage struct {
+	Title       string
+	Version     string
+	Description string
+	Operations  []docsOperation
+}
+
+// openAPIHandler serves the OpenAPI document describing the routes of the server.
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := fs.ReadFile(assetFS, openAPIFile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// docsHandler renders the OpenAPI document as a page listing every operation.
+// The page is rendered on the server, so it needs no scripts from other origins.
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	doc, err := loadOpenAPI(assetFS)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Load OpenAPI document failed", "error", err)
+		http.Error(w, "Invalid OpenAPI document", http.StatusInternalServerError)
+		return
+	}
+	page := docsPage{
+		Title:       doc.Info.Title,
+		Version:     doc.Info.Version,
+		Description: doc.Info.Description,
+	}
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		for _, method := range openAPIMethods {
+			op, ok := doc.Paths[path][method]
+			if !ok {
+				continue
+			}
+			responses := make([]string, 0, len(op.Responses))
+			for status := range op.Responses {
+				responses = append(responses, status)
+			}
+			slices.Sort(responses)
+			page.Operations = append(page.Operations, docsOperation{
+				Method:      strings.ToUpper(method),
+				Path:        path,
+				Summary:     op.Summary,
+				Description: op.Description,
+				Public:      op.Security != nil && len(*op.Security) == 0,
+				Responses:   responses,
+			})
+		}
+	}
+	renderPage(w, "docs", page)
+}
+
+// loadOpenAPI reads and parses the OpenAPI document from the asset file system.
+func loadOpenAPI(fsys fs.FS) (openAPIDoc, error) {
+	var doc openAPIDoc
+	data, err := fs.ReadFile(fsys, openAPIFile)
+	if err != nil {
+		return doc, err
+	}
+	err = json.Unmarshal(data, &doc)
+	return doc, err
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"todo-app/assets"
+)
+
+// specPath returns the OpenAPI path describing a mux pattern; subtree patterns ending in a slash
+// are described with a trailing {path} parameter.
+func specPath(pattern string) string {
+	if strings.HasSuffix(pattern, "/") {
+		return pattern + "{path}"
+	}
+	return pattern
+}
+
+// TestHandler_OpenAPI_DescribesRoutes tests that every registered route is described in the OpenAPI document
+// with the methods it accepts, and that the document describes no routes the server does not have.
+func TestHandler_OpenAPI_DescribesRoutes(t *testing.T) {
+	doc, err := loadOpenAPI(assets.Embedded())
+	if err != nil {
+		t.Fatalf("loadOpenAPI failed: %v", err)
+	}
+	registered := []string{}
+	for _, route := range routes() {
+		path := specPath(route.pattern)
+		registered = append(registered, path)
+		if len(doc.Paths[path]) == 0 {
+			t.Errorf("route %s is not described in %s", route.pattern, openAPIFile)
+			continue
+		}
+		described := []string{}
+		for method := range doc.Paths[path] {
+			described = append(described, strings.ToUpper(method))
+		}
+		methods := slices.Clone(route.methods)
+		slices.Sort(methods)
+		slices.Sort(described)
+		if !slices.Equal(methods, described) {
+			t.Errorf("route %s accepts %v but %s describes %v", route.pattern, methods, openAPIFile, described)
+		}
+	}
+	for path, operations := range doc.Paths {
+		if !slices.Contains(registered, path) {
+			t.Errorf("%s describes %s, which is not registered in AddRoutes", openAPIFile, path)
+		}
+		for method, op := range operations {
+			if !slices.Contains(openAPIMethods, method) {
+				t.Errorf("%s has unknown operation %q", path, method)
+			}
+			if op.Summary == "" || len(op.Responses) == 0 {
+				t.Errorf("%s %s needs a summary and responses", method, path)
+			}
+		}
+	}
+}
+
+// TestHandler_OpenAPI_References tests that every $ref in the document points at a defined component.
+func TestHandler_OpenAPI_References(t *testing.T) {
+	data, err := fs.ReadFile(assets.Embedded(), openAPIFile)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, schema := range []string{"Item", "Error"} {
+		if lookupRef(spec, "#/components/schemas/"+schema) == nil {
+			t.Errorf("expected the %s schema", schema)
+		}
+	}
+
+	var walk func(node any)
+	walk = func(node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok && lookupRef(spec, ref) == nil {
+				t.Errorf("unresolved reference %s", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(spec)
+}
+
+// lookupRef resolves a local JSON pointer such as #/components/schemas/Item, or returns nil.
+func lookupRef(spec map[string]any, ref string) any {
+	var node any = spec
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = m[key]
+	}
+	return node
+}
+
+// TestHandler_OpenAPI_Served tests that the document and docs page are served without a token.
+func TestHandler_OpenAPI_Served(t *testing.T) {
+	setupAuth(t, "alice")
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+
+	w := httptest.NewRecorder()
+	Wrap(mux).ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected the JSON document, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var doc openAPIDoc
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil || doc.Paths["/get/{itemid}"] == nil {
+		t.Errorf("unexpected document: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	Wrap(mux).ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "<code>/delete/{itemid}</code>") || !strings.Contains(body, "Delete an item") {
+		t.Errorf("expected the docs page to list operations, got %d %s", w.Code, body)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todo-app/actor"
+	"todo-app/auth"
+)
+
+// shareRequest is the body accepted by POST /shares.
+type shareRequest struct {
+	List string `json:"list,omitempty"`
+	User string `json:"user"`
+	Role string `json:"role"`
+}
+
+// sharesHandler lists who a list is shared with (GET) or shares it with a user (POST).
+// The list defaults to the caller's own; managing another user's list needs the admin role on it.
+func sharesHandler(w http.ResponseWriter, r *http.Request) {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		shares, err := actorInstance.Shares(r.Context(), r.URL.Query().Get("list"))
+		if err != nil {
+			actorError(w, err, shareErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shares)
+	case http.MethodPost:
+		var request shareRequest
+		if !decodeJSON(w, r, &request) {
+			return
+		}
+		role, err := auth.ParseRole(request.Role)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		share, err := actorInstance.Share(r.Context(), request.List, request.User, role)
+		if err != nil {
+			actorError(w, err, shareErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(share)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// shareByUserHandler stops sharing a list with a user.
+func shareByUserHandler(w http.ResponseWriter, r *http.Request) {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := r.PathValue("user")
+	if err := actorInstance.Unshare(r.Context(), r.URL.Query().Get("list"), user); err != nil {
+		actorError(w, err, shareErrorStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"unshared": user})
+}
+
+// shareErrorStatus returns the HTTP status for a failed share command.
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, actor.ErrListNotFound), errors.Is(err, auth.ErrShareNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, auth.ErrInvalidUser), errors.Is(err, auth.ErrInvalidRole), errors.Is(err, auth.ErrShareWithOwner):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
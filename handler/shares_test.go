@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/actor"
+	"todo-app/auth"
+)
+
+// TestHandler_Shares_ShareListUnshare tests sharing a list through the API.
+func TestHandler_Shares_ShareListUnshare(t *testing.T) {
+	setupMockActor()
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+
+	req := httptest.NewRequest("POST", "/shares", strings.NewReader(`{"list":"alice","user":"bob","role":"editor"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/shares?list=alice", nil))
+	var shares []auth.Share
+	if err := json.NewDecoder(w.Body).Decode(&shares); err != nil || len(shares) != 1 || shares[0].Role != auth.RoleEditor {
+		t.Fatalf("unexpected shares: %+v %v", shares, err)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("DELETE", "/shares/bob?list=alice", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("DELETE", "/shares/bob?list=alice", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a removed share, got %d", w.Code)
+	}
+}
+
+// TestHandler_Shares_InvalidRole tests that unknown roles are rejected.
+func TestHandler_Shares_InvalidRole(t *testing.T) {
+	setupMockActor()
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/shares", strings.NewReader(`{"user":"bob","role":"owner"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+// TestHandler_Shares_Forbidden tests that denied operations return 403 with a JSON error body.
+func TestHandler_Shares_Forbidden(t *testing.T) {
+	actorInstance = &mockActor{deny: fmt.Errorf("%w: admin role required", actor.ErrForbidden)}
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/shares", strings.NewReader(`{"list":"alice","user":"carol","role":"viewer"}`)),
+		httptest.NewRequest("POST", "/create", strings.NewReader(`{"description":"x","owner":"alice"}`)),
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected 403, got %d", req.URL.Path, w.Code)
+		}
+		var body errorBody
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Code != "forbidden" || !strings.Contains(body.Error, "admin role required") {
+			t.Errorf("%s: unexpected error body: %+v %v", req.URL.Path, body, err)
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected JSON content type, got %s", req.URL.Path, w.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// streamsDone is closed when the server shuts down, ending the /events streams and /ws connections
+// that would otherwise keep http.Server.Shutdown waiting.
+var streamsDone = make(chan struct{})
+
+var closeStreamsOnce sync.Once
+
+// stopWebhooks cancels webhook delivery; webhooksDone is closed once the last events are queued.
+var (
+	stopWebhooks context.CancelFunc
+	webhooksDone chan struct{}
+)
+
+// CloseStreams ends the long-lived /events and /ws connections. Register it with http.Server.RegisterOnShutdown.
+func CloseStreams() {
+	closeStreamsOnce.Do(func() { close(streamsDone) })
+}
+
+// Shutdown stops the actor once the commands already sent are processed,
+// then stops webhook delivery after queueing the events they produced.
+// Call it after http.Server.Shutdown so no new commands arrive.
+func Shutdown(ctx context.Context) error {
+	if stopper, ok := actorInstance.(interface{ Stop(context.Context) error }); ok {
+		if err := stopper.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	if stopWebhooks != nil {
+		stopWebhooks()
+		select {
+		case <-webhooksDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
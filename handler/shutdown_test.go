@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"todo-app/events"
+)
+
+// stoppingActor is a mock actor that records Stop calls.
+type stoppingActor struct {
+	mockActor
+	stopped bool
+	err     error
+}
+
+func (m *stoppingActor) Stop(ctx context.Context) error {
+	m.stopped = true
+	return m.err
+}
+
+// resetStreams reopens the streams closed by CloseStreams once the test ends.
+func resetStreams(t *testing.T) {
+	t.Cleanup(func() {
+		streamsDone = make(chan struct{})
+		closeStreamsOnce = sync.Once{}
+	})
+}
+
+// TestHandler_CloseStreamsEndsEvents tests that CloseStreams ends an open /events stream.
+func TestHandler_CloseStreamsEndsEvents(t *testing.T) {
+	resetStreams(t)
+	eventBroker = events.NewBroker(10)
+	server := httptest.NewServer(http.HandlerFunc(eventsHandler))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	CloseStreams()
+	CloseStreams()
+
+	read := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, resp.Body)
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Errorf("expected the stream to end cleanly, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the stream to end after CloseStreams")
+	}
+}
+
+// TestHandler_ShutdownStopsActor tests that Shutdown stops the actor and webhook delivery.
+func TestHandler_ShutdownStopsActor(t *testing.T) {
+	mock := &stoppingActor{}
+	actorInstance = mock
+	eventBroker = events.NewBroker(10)
+	if err := InitWebhooks(context.Background(), t.TempDir()); err != nil {
+		t.Fatalf("InitWebhooks failed: %v", err)
+	}
+	t.Cleanup(func() { stopWebhooks, webhooksDone = nil, nil })
+
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !mock.stopped {
+		t.Error("expected the actor to be stopped")
+	}
+	select {
+	case <-webhooksDone:
+	default:
+		t.Error("expected webhook delivery to be stopped")
+	}
+}
+
+// TestHandler_ShutdownActorError tests that Shutdown reports an actor that fails to drain.
+func TestHandler_ShutdownActorError(t *testing.T) {
+	actorInstance = &stoppingActor{err: context.DeadlineExceeded}
+	if err := Shutdown(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"todo-app/logging"
+)
+
+const (
+	requestIDHeader   string = "X-Request-ID"
+	traceparentHeader string = "traceparent"
+
+	// maxRequestIDLength bounds the client supplied request IDs copied into logs and responses.
+	maxRequestIDLength = 128
+)
+
+// traceRequests gives every request a trace ID for the log records written with its context, echoes it in the
+// X-Request-ID response header and writes an access log line once the request is served.
+// The ID is taken from a W3C traceparent header, then X-Request-ID, and generated when neither is usable.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		traceID := requestTraceID(r)
+		w.Header().Set(requestIDHeader, traceID)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		r = r.WithContext(logging.WithTraceID(r.Context(), traceID))
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			// nothing written, net/http sends 200
+			status = http.StatusOK
+		}
+		slog.InfoContext(r.Context(), "HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", recorder.bytes,
+			"latency", time.Since(started),
+			"remote", clientIP(r))
+	})
+}
+
+// requestTraceID returns the trace ID for the request, from its headers or newly generated.
+func requestTraceID(r *http.Request) string {
+	if traceID, ok := parseTraceparent(r.Header.Get(traceparentHeader)); ok {
+		return traceID
+	}
+	if id := r.Header.Get(requestIDHeader); validRequestID(id) {
+		return id
+	}
+	return logging.GenerateID()
+}
+
+// parseTraceparent returns the trace-id field of a version 00 W3C traceparent header,
+// formatted as 00-<32 hex trace-id>-<16 hex parent-id>-<2 hex flags>.
+func parseTraceparent(value string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || parts[0] != "00" || !isLowerHex(parts[1], 32) || !isLowerHex(parts[2], 16) || !isLowerHex(parts[3], 2) {
+		return "", false
+	}
+	// all-zero IDs are invalid
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// isLowerHex reports whether s is exactly n lowercase hex digits.
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// validRequestID accepts short IDs of letters, digits and the punctuation common in request IDs,
+// so a client cannot inject anything into the logs or response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("-_.:", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// statusRecorder captures the status code and body size written by a handler.
+// It passes flushing and hijacking through, so /events and /ws keep working behind it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(data)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.NewResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/logging"
+)
+
+// captureLog sends the default logger to a buffer for the rest of the test, adding trace IDs like the server's logger.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(traceLogHandler{slog.NewTextHandler(&buf, nil)}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// traceLogHandler adds the trace ID from the context to each record.
+type traceLogHandler struct {
+	slog.Handler
+}
+
+func (h traceLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if traceID, ok := logging.TraceID(ctx); ok {
+		r.AddAttrs(slog.String(string(logging.TraceIDKey), traceID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// TestHandler_Trace_GeneratesID tests that requests without an ID get a generated one in the response and context.
+func TestHandler_Trace_GeneratesID(t *testing.T) {
+	var seen string
+	h := traceRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = logging.TraceID(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/get", nil))
+
+	id := w.Header().Get(requestIDHeader)
+	if len(id) != 32 {
+		t.Fatalf("expected a generated 32 character ID, got %q", id)
+	}
+	if seen != id {
+		t.Errorf("expected the context to carry %q, got %q", id, seen)
+	}
+}
+
+// TestHandler_Trace_IncomingIDs tests which incoming traceparent and X-Request-ID headers are accepted.
+func TestHandler_Trace_IncomingIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		requestID   string
+		want        string
+	}{
+		{"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "ignored", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"request id", "", "abc-123_x.y:z", "abc-123_x.y:z"},
+		{"bad traceparent falls back", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "fallback", "fallback"},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "fallback", "fallback"},
+		{"unknown version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "fallback", "fallback"},
+		{"unsafe request id", "", "bad id\r\n", ""},
+		{"long request id", "", strings.Repeat("a", maxRequestIDLength+1), ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/get", nil)
+			if tc.traceparent != "" {
+				req.Header.Set(traceparentHeader, tc.traceparent)
+			}
+			req.Header.Set(requestIDHeader, tc.requestID)
+			w := httptest.NewRecorder()
+			traceRequests(http.NotFoundHandler()).ServeHTTP(w, req)
+
+			got := w.Header().Get(requestIDHeader)
+			if tc.want != "" && got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+			if tc.want == "" && (got == tc.requestID || len(got) != 32) {
+				t.Errorf("expected a generated ID, got %q", got)
+			}
+		})
+	}
+}
+
+// TestHandler_Trace_AccessLog tests the access log line written for each request.
+func TestHandler_Trace_AccessLog(t *testing.T) {
+	buf := captureLog(t)
+	h := traceRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.InfoContext(r.Context(), "inside handler")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest("POST", "/create", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"Trace ID"=req-42`) {
+		t.Errorf("expected the handler's log line to carry the trace ID: %s", lines[0])
+	}
+	for _, want := range []string{`msg="HTTP request"`, "method=POST", "path=/create", "status=201", "bytes=5", "latency=", `"Trace ID"=req-42`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("expected %s in access log line: %s", want, lines[1])
+		}
+	}
+}
+
+// TestHandler_Trace_KeepsStreaming tests that the wrapped writer still supports flushing for /events.
+func TestHandler_Trace_KeepsStreaming(t *testing.T) {
+	var flushable bool
+	h := traceRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flushable = w.(http.Flusher)
+		_, hijackable := w.(http.Hijacker)
+		if !hijackable {
+			t.Error("expected the wrapped writer to support hijacking")
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil))
+	if !flushable {
+		t.Error("expected the wrapped writer to support flushing")
+	}
+}
+
+// TestHandler_Trace_Wrapped tests that responses from the full middleware chain carry the request ID, even when rejected.
+func TestHandler_Trace_Wrapped(t *testing.T) {
+	setupMockActor()
+	req := httptest.NewRequest("GET", "/get", nil)
+	req.Header.Set(requestIDHeader, "from-client")
+	req.Header.Set("Authorization", "Bearer invalid")
+	w := serveWrapped(t, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if got := w.Header().Get(requestIDHeader); got != "from-client" {
+		t.Errorf("expected the request ID to be echoed, got %q", got)
+	}
+}
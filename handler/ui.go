@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-app/actor"
+	"todo-app/assets"
+	"todo-app/auth"
+	"todo-app/storage"
+)
+
+// statusLabels are the human readable names for each status.
+var statusLabels = map[string]string{
+	"not_started": "Not started",
+	"in_progress": "In progress",
+	"is_finished": "Finished",
+}
+
+// listPage is the data rendered by the list template.
+type listPage struct {
+	User      string
+	Items     []storage.Item
+	Filter    string
+	Statuses  []string
+	Notice    string
+	Error     string
+	CSRFToken string
+}
+
+// confirmPage is the data rendered by the delete confirmation template.
+type confirmPage struct {
+	Item      storage.Item
+	Filter    string
+	CSRFToken string
+}
+
+// uiFuncs are the helpers available to the UI templates.
+var uiFuncs = template.FuncMap{
+	"statusLabel": func(status string) string {
+		if label, ok := statusLabels[status]; ok {
+			return label
+		}
+		return status
+	},
+}
+
+// assetFS holds the templates and static files, embedded unless overridden with -assets-dir.
+var assetFS fs.FS = assets.Embedded()
+
+// reloadTemplates re-parses the templates on every render so edits on disk show up without a restart.
+var reloadTemplates bool
+
+// uiTemplates holds the server-rendered pages, parsed once at startup.
+var uiTemplates = template.Must(parseTemplates(assets.Embedded()))
+
+// InitAssets switches templates and static files to the given file system.
+// With reload set the templates are parsed again for every page, for development against a directory on disk.
+func InitAssets(fsys fs.FS, reload bool) error {
+	tpl, err := parseTemplates(fsys)
+	if err != nil {
+		return err
+	}
+	assetFS = fsys
+	uiTemplates = tpl
+	reloadTemplates = reload
+	return nil
+}
+
+// parseTemplates parses the UI template set from the templates folder of fsys.
+func parseTemplates(fsys fs.FS) (*template.Template, error) {
+	return template.New("ui").Funcs(uiFuncs).ParseFS(fsys, "templates/*.html")
+}
+
+// assetHandler serves a folder of the asset file system below the given URL prefix.
+func assetHandler(prefix string, dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, err := fs.Sub(assetFS, dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.StripPrefix(prefix, http.FileServerFS(sub)).ServeHTTP(w, r)
+	})
+}
+
+// dynamicListHandler renders the todo list with forms to create, edit and delete items.
+// An optional status query parameter filters the list.
+func dynamicListHandler(w http.ResponseWriter, r *http.Request) {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// an empty list is reported as an error by storage, so render it as no items
+	items, err := actorInstance.ListAll(r.Context())
+	if err != nil && !errors.Is(err, storage.ErrNoItems) {
+		actorError(w, err, http.StatusInternalServerError)
+		return
+	}
+	filter := validFilter(r.URL.Query().Get("status"))
+	identity, _ := auth.FromContext(r.Context())
+
+	page := listPage{
+		User:      identity.User,
+		Items:     filterItems(items, filter),
+		Filter:    filter,
+		Statuses:  storage.Statuses,
+		Notice:    listNotice(r.URL.Query()),
+		Error:     listErrors[r.URL.Query().Get("error")],
+		CSRFToken: csrfToken(r),
+	}
+	renderPage(w, "list", page)
+}
+
+// uiCreateHandler handles the create form and redirects back to the list.
+func uiCreateHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireUIPost(w, r) {
+		return
+	}
+	filter := validFilter(r.PostFormValue("filter"))
+	item, err := actorInstance.Create(r.Context(), strings.TrimSpace(r.PostFormValue("description")), r.PostFormValue("status"))
+	if err != nil {
+		redirectToListError(w, r, filter, err)
+		return
+	}
+	redirectToList(w, r, filter, "created", item.ID)
+}
+
+// uiUpdateHandler handles the inline edit form for a single item and redirects back to the list.
+func uiUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireUIPost(w, r) {
+		return
+	}
+	filter := validFilter(r.PostFormValue("filter"))
+	id, err := strconv.Atoi(r.PathValue("itemid"))
+	if err != nil {
+		redirectToListError(w, r, filter, storage.ErrInvalidID)
+		return
+	}
+	_, err = actorInstance.Update(r.Context(), id, strings.TrimSpace(r.PostFormValue("description")), r.PostFormValue("status"))
+	if err != nil {
+		redirectToListError(w, r, filter, err)
+		return
+	}
+	redirectToList(w, r, filter, "updated", id)
+}
+
+// uiDeleteHandler shows a confirmation page (GET) and deletes the item once confirmed (POST).
+func uiDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("itemid"))
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		item, err := actorInstance.List(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		renderPage(w, "confirm", confirmPage{Item: item, Filter: validFilter(r.URL.Query().Get("status")), CSRFToken: csrfToken(r)})
+	case http.MethodPost:
+		filter := validFilter(r.PostFormValue("filter"))
+		if err := actorInstance.Delete(r.Context(), id); err != nil {
+			redirectToListError(w, r, filter, err)
+			return
+		}
+		redirectToList(w, r, filter, "deleted", id)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// requireUIPost rejects anything but a POST from the UI forms.
+func requireUIPost(w http.ResponseWriter, r *http.Request) bool {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return false
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// listNotices are the notices the list shows after a form succeeds, by the notice code in the redirect.
+var listNotices = map[string]string{
+	"created": "Created item",
+	"updated": "Updated item",
+	"deleted": "Deleted item",
+}
+
+// listErrors are the errors the list shows after a form fails, by the error code in the redirect.
+// The list only shows these fixed texts, so a link cannot put its own message on the page.
+var listErrors = map[string]string{
+	"invalid_id":        "Invalid item ID",
+	"empty_description": "Description cannot be empty",
+	"long_description":  "Description is too long",
+	"invalid_status":    "Invalid status value",
+	"not_found":         "Item not found",
+	"forbidden":         "Permission denied",
+	"timeout":           "The request timed out, please try again",
+	"shutting_down":     "The server is shutting down, please try again",
+	"failed":            "The change could not be saved",
+}
+
+// listErrorCode returns the code of the error shown for a failed form.
+func listErrorCode(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrInvalidID):
+		return "invalid_id"
+	case errors.Is(err, storage.ErrEmptyDescription):
+		return "empty_description"
+	case errors.Is(err, storage.ErrLongDescription):
+		return "long_description"
+	case errors.Is(err, storage.ErrInvalidStatus):
+		return "invalid_status"
+	case errors.Is(err, storage.ErrItemNotFound), errors.Is(err, actor.ErrListNotFound):
+		return "not_found"
+	case errors.Is(err, actor.ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	case errors.Is(err, actor.ErrStopped):
+		return "shutting_down"
+	default:
+		return "failed"
+	}
+}
+
+// listNotice returns the notice for the notice code and item ID in the list query, or nothing for unknown codes.
+func listNotice(query url.Values) string {
+	text, ok := listNotices[query.Get("notice")]
+	id, err := strconv.Atoi(query.Get("item"))
+	if !ok || err != nil {
+		return ""
+	}
+	return text + " " + strconv.Itoa(id)
+}
+
+// redirectToList completes a post-redirect-get flow back to the filtered list with a notice about an item.
+func redirectToList(w http.ResponseWriter, r *http.Request, filter string, notice string, id int) {
+	query := url.Values{"notice": {notice}, "item": {strconv.Itoa(id)}}
+	if filter != "" {
+		query.Set("status", filter)
+	}
+	http.Redirect(w, r, "/list?"+query.Encode(), http.StatusSeeOther)
+}
+
+// redirectToListError completes a post-redirect-get flow back to the filtered list with the code of an error.
+func redirectToListError(w http.ResponseWriter, r *http.Request, filter string, err error) {
+	code := listErrorCode(err)
+	if code == "failed" {
+		slog.ErrorContext(r.Context(), "UI form failed", "path", r.URL.Path, "error", err)
+	}
+	query := url.Values{"error": {code}}
+	if filter != "" {
+		query.Set("status", filter)
+	}
+	http.Redirect(w, r, "/list?"+query.Encode(), http.StatusSeeOther)
+}
+
+// renderPage executes a named UI template.
+func renderPage(w http.ResponseWriter, name string, data any) {
+	tpl := uiTemplates
+	if reloadTemplates {
+		fresh, err := parseTemplates(assetFS)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tpl = fresh
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tpl.ExecuteTemplate(w, name, data); err != nil {
+		slog.Error("Render page failed", "page", name, "error", err)
+	}
+}
+
+// validFilter returns the status filter if it is a known status, otherwise no filter.
+func validFilter(status string) string {
+	if storage.ValidStatus(status) {
+		return status
+	}
+	return ""
+}
+
+// filterItems returns the items with the given status ordered by ID, or all items when status is empty.
+func filterItems(items storage.Items, status string) []storage.Item {
+	return sortedItems(items.WithStatus(status))
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-app/actor"
+	"todo-app/assets"
+	"todo-app/storage"
+)
+
+// serveUI sends a request through the registered routes.
+func serveUI(method string, target string, form url.Values) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestHandler_UI_ListFilter tests that the list can be filtered by status.
+func TestHandler_UI_ListFilter(t *testing.T) {
+	actorInstance = &mockActor{items: map[int]storage.Item{
+		1: {ID: 1, Description: "Started", Status: "in_progress"},
+		2: {ID: 2, Description: "Waiting", Status: "not_started"},
+	}}
+
+	w := serveUI("GET", "/list?status=in_progress", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Started") || strings.Contains(body, "Waiting") {
+		t.Errorf("unexpected filtered list: %s", body)
+	}
+}
+
+// TestHandler_UI_ListErrors tests that only an empty store renders as an empty list,
+// and that other actor errors are reported instead of hidden.
+func TestHandler_UI_ListErrors(t *testing.T) {
+	actorInstance = &mockActor{deny: storage.ErrNoItems}
+	if w := serveUI("GET", "/list", nil); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for an empty store, got %d", w.Code)
+	}
+
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{actor.ErrStopped, http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable},
+		{actor.ErrForbidden, http.StatusForbidden},
+		{errors.New("disk on fire"), http.StatusInternalServerError},
+	} {
+		actorInstance = &mockActor{deny: tc.err}
+		if w := serveUI("GET", "/list", nil); w.Code != tc.code {
+			t.Errorf("%v: expected %d, got %d", tc.err, tc.code, w.Code)
+		}
+	}
+}
+
+// TestHandler_UI_EscapesDescriptions tests that descriptions are HTML escaped.
+func TestHandler_UI_EscapesDescriptions(t *testing.T) {
+	actorInstance = &mockActor{items: map[int]storage.Item{
+		1: {ID: 1, Description: "<script>alert(1)</script>", Status: "not_started"},
+	}}
+
+	body := serveUI("GET", "/list", nil).Body.String()
+	if strings.Contains(body, "<script>alert(1)</script>") {
+		t.Errorf("description was not escaped: %s", body)
+	}
+}
+
+// TestHandler_UI_Create tests the create form redirects back to the list.
+func TestHandler_UI_Create(t *testing.T) {
+	setupMockActor()
+
+	w := serveUI("POST", "/list/create", url.Values{"description": {"From form"}, "status": {"not_started"}, "filter": {"not_started"}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "/list?") || !strings.Contains(location, "status=not_started") || !strings.Contains(location, "notice=created") {
+		t.Errorf("unexpected redirect: %s", location)
+	}
+	if item := actorInstance.(*mockActor).items[2]; item.Description != "From form" {
+		t.Errorf("item not created: %+v", item)
+	}
+	if body := serveUI("GET", location, nil).Body.String(); !strings.Contains(body, "Created item 2") {
+		t.Errorf("expected notice on the list: %s", body)
+	}
+}
+
+// TestHandler_UI_CreateRequiresPost tests that the create endpoint rejects GET.
+func TestHandler_UI_CreateRequiresPost(t *testing.T) {
+	setupMockActor()
+	if w := serveUI("GET", "/list/create", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", w.Code)
+	}
+}
+
+// TestHandler_UI_Update tests the inline edit form.
+func TestHandler_UI_Update(t *testing.T) {
+	setupMockActor()
+
+	w := serveUI("POST", "/list/update/1", url.Values{"description": {"Edited"}, "status": {"is_finished"}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	if item := actorInstance.(*mockActor).items[1]; item.Description != "Edited" || item.Status != "is_finished" {
+		t.Errorf("item not updated: %+v", item)
+	}
+}
+
+// TestHandler_UI_UpdateNotFound tests that errors are shown on the list after the redirect.
+func TestHandler_UI_UpdateNotFound(t *testing.T) {
+	setupMockActor()
+
+	w := serveUI("POST", "/list/update/999", url.Values{"description": {"Edited"}, "status": {"is_finished"}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); !strings.Contains(location, "error=") {
+		t.Errorf("expected error in redirect: %s", location)
+	}
+}
+
+// TestHandler_UI_MessagesAreFixed tests that the list only shows its own notices and errors,
+// so a link cannot put free text on the page.
+func TestHandler_UI_MessagesAreFixed(t *testing.T) {
+	setupMockActor()
+
+	body := serveUI("GET", "/list?"+url.Values{"notice": {"Your account is locked"}, "error": {"Call 555-0100"}}.Encode(), nil).Body.String()
+	if strings.Contains(body, "locked") || strings.Contains(body, "555-0100") {
+		t.Errorf("expected free text from the query to be ignored: %s", body)
+	}
+	body = serveUI("GET", "/list?notice=deleted&item=x", nil).Body.String()
+	if strings.Contains(body, `class="notice"`) {
+		t.Errorf("expected no notice without a numeric item: %s", body)
+	}
+
+	w := serveUI("POST", "/list/update/abc", url.Values{"description": {"Edited"}})
+	location := w.Header().Get("Location")
+	if !strings.Contains(location, "error=invalid_id") {
+		t.Fatalf("expected an error code in the redirect: %s", location)
+	}
+	if body := serveUI("GET", location, nil).Body.String(); !strings.Contains(body, "Invalid item ID") {
+		t.Errorf("expected the error on the list: %s", body)
+	}
+}
+
+// TestHandler_UI_DeleteConfirm tests the delete confirmation page and the confirmed delete.
+func TestHandler_UI_DeleteConfirm(t *testing.T) {
+	setupMockActor()
+
+	w := serveUI("GET", "/list/delete/1", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `action="/list/delete/1"`) {
+		t.Fatalf("unexpected confirmation page: %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := actorInstance.(*mockActor).items[1]; !ok {
+		t.Fatal("item deleted before confirmation")
+	}
+
+	w = serveUI("POST", "/list/delete/1", url.Values{})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	if _, ok := actorInstance.(*mockActor).items[1]; ok {
+		t.Error("item not deleted after confirmation")
+	}
+}
+
+// TestHandler_UI_StaticAssets tests that embedded static files and the about page are served.
+func TestHandler_UI_StaticAssets(t *testing.T) {
+	for _, target := range []string{"/static/css/app.css", "/static/js/list.js", "/about/"} {
+		w := serveUI("GET", target, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected 200 for %s, got %d", target, w.Code)
+		}
+	}
+}
+
+// TestHandler_UI_AssetsReload tests that templates are re-read from disk when reloading is enabled.
+func TestHandler_UI_AssetsReload(t *testing.T) {
+	setupMockActor()
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, assets.Embedded()); err != nil {
+		t.Fatalf("CopyFS failed: %v", err)
+	}
+	if err := InitAssets(os.DirFS(dir), true); err != nil {
+		t.Fatalf("InitAssets failed: %v", err)
+	}
+	defer InitAssets(assets.Embedded(), false)
+
+	listFile := filepath.Join(dir, "templates", "list.html")
+	if err := os.WriteFile(listFile, []byte(`{{define "list"}}edited on disk{{end}}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if body := serveUI("GET", "/list", nil).Body.String(); body != "edited on disk" {
+		t.Errorf("expected reloaded template, got %s", body)
+	}
+}
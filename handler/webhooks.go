@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todo-app/webhook"
+)
+
+// webhookDispatcher delivers change events to registered webhooks.
+var webhookDispatcher *webhook.Dispatcher
+
+// webhookRequest is the body accepted by POST /webhooks.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// InitWebhooks loads the webhook registry from the data folder and starts delivering the actor's change feed.
+// InitActor must be called first.
+func InitWebhooks(ctx context.Context, folder string) error {
+	dispatcher, err := webhook.NewDispatcher(ctx, folder)
+	if err != nil {
+		return err
+	}
+	webhookDispatcher = dispatcher
+	if eventBroker != nil {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		stopWebhooks, webhooksDone = cancel, done
+		go func() {
+			defer close(done)
+			dispatcher.Run(runCtx, eventBroker)
+		}()
+	}
+	return nil
+}
+
+// webhooksHandler lists registered webhooks (GET) or registers a new one (POST).
+func webhooksHandler(w http.ResponseWriter, r *http.Request) {
+	if webhookDispatcher == nil {
+		http.Error(w, "Webhooks not initialized", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		hooks := webhookDispatcher.Hooks()
+		// secrets are only shown once, when the hook is registered
+		for i := range hooks {
+			hooks[i].Secret = ""
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(hooks)
+	case http.MethodPost:
+		var request webhookRequest
+		if !decodeJSON(w, r, &request) {
+			return
+		}
+		hook, err := webhookDispatcher.Register(r.Context(), request.URL, request.Secret, request.Events)
+		if errors.Is(err, webhook.ErrInvalidURL) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(hook)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// webhookByIDHandler removes a registered webhook.
+func webhookByIDHandler(w http.ResponseWriter, r *http.Request) {
+	if webhookDispatcher == nil {
+		http.Error(w, "Webhooks not initialized", http.StatusInternalServerError)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.PathValue("id")
+	if err := webhookDispatcher.Unregister(r.Context(), id); err != nil {
+		if errors.Is(err, webhook.ErrHookNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"deleted": id})
+}
+
+// webhookLogHandler returns the delivery log and the deliveries still queued.
+func webhookLogHandler(w http.ResponseWriter, r *http.Request) {
+	if webhookDispatcher == nil {
+		http.Error(w, "Webhooks not initialized", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Pending []webhook.Delivery `json:"pending"`
+		Log     []webhook.LogEntry `json:"log"`
+	}{Pending: webhookDispatcher.Pending(), Log: webhookDispatcher.Log()})
+}
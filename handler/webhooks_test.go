@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/auth"
+	"todo-app/webhook"
+)
+
+// setupWebhooks initializes a webhook dispatcher in a temp folder.
+func setupWebhooks(t *testing.T) {
+	dispatcher, err := webhook.NewDispatcher(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+	webhookDispatcher = dispatcher
+}
+
+// TestHandler_Webhooks_RegisterListDelete tests the webhook admin API.
+func TestHandler_Webhooks_RegisterListDelete(t *testing.T) {
+	setupWebhooks(t)
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+	admin := auth.WithIdentity(context.Background(), auth.Identity{User: "root", Scopes: []string{auth.ScopeAdmin}})
+
+	// register
+	body := `{"url":"http://example.com/hook","events":["item.created"]}`
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(body)).WithContext(admin)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var hook webhook.Hook
+	if err := json.NewDecoder(w.Body).Decode(&hook); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if hook.ID == "" || hook.Secret == "" {
+		t.Errorf("expected generated id and secret: %+v", hook)
+	}
+
+	// list hides secrets
+	req = httptest.NewRequest("GET", "/webhooks", nil).WithContext(admin)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var hooks []webhook.Hook
+	if err := json.NewDecoder(w.Body).Decode(&hooks); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].Secret != "" {
+		t.Errorf("unexpected hooks: %+v", hooks)
+	}
+
+	// delete
+	req = httptest.NewRequest("DELETE", "/webhooks/"+hook.ID, nil).WithContext(admin)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	req = httptest.NewRequest("DELETE", "/webhooks/"+hook.ID, nil).WithContext(admin)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+// TestHandler_Webhooks_RequireAdmin tests that the webhook API is restricted to admins.
+func TestHandler_Webhooks_RequireAdmin(t *testing.T) {
+	setupWebhooks(t)
+	mux := http.NewServeMux()
+	AddRoutes(mux)
+	user := auth.WithIdentity(context.Background(), auth.Identity{User: "alice"})
+
+	req := httptest.NewRequest("GET", "/webhooks", nil).WithContext(user)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+// TestHandler_Webhooks_InvalidURL tests registering a webhook with a bad URL.
+func TestHandler_Webhooks_InvalidURL(t *testing.T) {
+	setupWebhooks(t)
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(`{"url":"not a url"}`))
+	w := httptest.NewRecorder()
+	webhooksHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+// TestHandler_Webhooks_Log tests the delivery log endpoint.
+func TestHandler_Webhooks_Log(t *testing.T) {
+	setupWebhooks(t)
+	req := httptest.NewRequest("GET", "/webhooks/log", nil)
+	w := httptest.NewRecorder()
+	webhookLogHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"pending"`) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"todo-app/actor"
+	"todo-app/events"
+	"todo-app/storage"
+	"todo-app/websocket"
+)
+
+const (
+	wsActionCreate string = "create"
+	wsActionUpdate string = "update"
+	wsActionDelete string = "delete"
+	wsActionList   string = "list"
+)
+
+// wsCommand is a JSON frame sent by a /ws client.
+type wsCommand struct {
+	Ref         string `json:"ref,omitempty"`
+	Action      string `json:"action"`
+	ID          int    `json:"id,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// wsMessage is a JSON frame sent to a /ws client, either a change event or the result of a command.
+type wsMessage struct {
+	Type  string         `json:"type"`
+	Ref   string         `json:"ref,omitempty"`
+	Event *events.Event  `json:"event,omitempty"`
+	Item  *storage.Item  `json:"item,omitempty"`
+	Items []storage.Item `json:"items,omitempty"`
+	Error string         `json:"error,omitempty"`
+	Code  string         `json:"code,omitempty"`
+}
+
+// wsHandler upgrades the request to a WebSocket that pushes change events
+// and accepts create/update/delete/list commands routed through the actor.
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	if actorInstance == nil {
+		http.Error(w, "Actor not initialized", http.StatusInternalServerError)
+		return
+	}
+	// browsers do not apply the same-origin policy to WebSockets, so reject pages from other sites
+	if !sameOrigin(r) {
+		http.Error(w, "Cross-origin WebSocket rejected", http.StatusForbidden)
+		return
+	}
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// push change events until the connection goes away
+	if eventBroker != nil {
+		_, ch, _ := eventBroker.Subscribe(0)
+		defer eventBroker.Unsubscribe(ch)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for open := true; open; {
+				var event events.Event
+				select {
+				case event, open = <-ch:
+				case <-streamsDone:
+					conn.CloseWithCode(websocket.CloseGoingAway, "server shutting down")
+					return
+				}
+				if !open || !canSee(r, event.Item) {
+					continue
+				}
+				if err := writeWSMessage(conn, wsMessage{Type: "event", Event: &event}); err != nil {
+					conn.Close()
+					return
+				}
+			}
+			select {
+			case <-done:
+				// handler already returning
+			default:
+				// dropped as a slow consumer, let the client reconnect and resync
+				conn.CloseWithCode(websocket.CloseGoingAway, "event stream overflow")
+			}
+		}()
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd wsCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			_ = writeWSMessage(conn, wsMessage{Type: "result", Error: "invalid command"})
+			continue
+		}
+		if err := writeWSMessage(conn, runWSCommand(r.Context(), cmd)); err != nil {
+			return
+		}
+	}
+}
+
+// sameOrigin reports whether the request has no Origin header (non-browser clients) or one matching the host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == r.Host
+}
+
+// runWSCommand executes a single client command against the actor.
+func runWSCommand(ctx context.Context, cmd wsCommand) wsMessage {
+	result := wsMessage{Type: "result", Ref: cmd.Ref}
+	var err error
+	switch cmd.Action {
+	case wsActionCreate:
+		var item storage.Item
+		if item, err = actorInstance.Create(ctx, cmd.Description, cmd.Status); err == nil {
+			result.Item = &item
+		}
+	case wsActionUpdate:
+		var item storage.Item
+		if item, err = actorInstance.Update(ctx, cmd.ID, cmd.Description, cmd.Status); err == nil {
+			result.Item = &item
+		}
+	case wsActionDelete:
+		err = actorInstance.Delete(ctx, cmd.ID)
+	case wsActionList:
+		var items storage.Items
+		if items, err = actorInstance.ListAll(ctx); err == nil {
+			result.Items = sortedItems(items)
+		}
+	default:
+		err = errors.New("unknown action")
+	}
+	if err != nil {
+		result.Error = err.Error()
+		switch {
+		case errors.Is(err, actor.ErrForbidden):
+			result.Code = "forbidden"
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+			result.Code = "timeout"
+		case errors.Is(err, actor.ErrStopped):
+			result.Code = "shutting_down"
+		}
+	}
+	return result
+}
+
+// writeWSMessage encodes and sends a message as a text frame.
+func writeWSMessage(conn *websocket.Conn, message wsMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// sortedItems returns the items ordered by ID.
+func sortedItems(items storage.Items) []storage.Item {
+	list := make([]storage.Item, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	slices.SortFunc(list, func(a, b storage.Item) int { return a.ID - b.ID })
+	return list
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/events"
+	"todo-app/storage"
+	"todo-app/websocket"
+)
+
+// dialWS starts a test server for wsHandler and connects a client to it.
+func dialWS(t *testing.T) *websocket.Conn {
+	server := httptest.NewServer(http.HandlerFunc(wsHandler))
+	t.Cleanup(server.Close)
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// sendWS sends a command and decodes the next message from the server.
+func sendWS(t *testing.T, conn *websocket.Conn, cmd wsCommand) wsMessage {
+	data, _ := json.Marshal(cmd)
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return readWS(t, conn)
+}
+
+// readWS decodes the next message from the server.
+func readWS(t *testing.T, conn *websocket.Conn) wsMessage {
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	var message wsMessage
+	if err := json.Unmarshal(reply, &message); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	return message
+}
+
+// TestHandler_WS_Commands tests create, update, list and delete commands over /ws.
+func TestHandler_WS_Commands(t *testing.T) {
+	setupMockActor()
+	eventBroker = nil
+	conn := dialWS(t)
+
+	created := sendWS(t, conn, wsCommand{Ref: "1", Action: wsActionCreate, Description: "From socket", Status: "not_started"})
+	if created.Type != "result" || created.Ref != "1" || created.Item == nil || created.Item.Description != "From socket" {
+		t.Fatalf("unexpected create result: %+v", created)
+	}
+
+	updated := sendWS(t, conn, wsCommand{Ref: "2", Action: wsActionUpdate, ID: created.Item.ID, Description: "Edited", Status: "in_progress"})
+	if updated.Error != "" || updated.Item.Status != "in_progress" {
+		t.Fatalf("unexpected update result: %+v", updated)
+	}
+
+	listed := sendWS(t, conn, wsCommand{Ref: "3", Action: wsActionList})
+	if len(listed.Items) != 2 || listed.Items[0].ID != 1 {
+		t.Fatalf("unexpected list result: %+v", listed)
+	}
+
+	deleted := sendWS(t, conn, wsCommand{Ref: "4", Action: wsActionDelete, ID: created.Item.ID})
+	if deleted.Error != "" {
+		t.Fatalf("unexpected delete result: %+v", deleted)
+	}
+
+	missing := sendWS(t, conn, wsCommand{Ref: "5", Action: wsActionDelete, ID: 999})
+	if missing.Error == "" {
+		t.Errorf("expected error deleting missing item: %+v", missing)
+	}
+}
+
+// TestHandler_WS_InvalidCommand tests that malformed frames and unknown actions return errors.
+func TestHandler_WS_InvalidCommand(t *testing.T) {
+	setupMockActor()
+	eventBroker = nil
+	conn := dialWS(t)
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("not json")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if reply := readWS(t, conn); reply.Error != "invalid command" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+
+	if reply := sendWS(t, conn, wsCommand{Action: "explode"}); reply.Error != "unknown action" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+}
+
+// TestHandler_WS_PushesEvents tests that change events are pushed to connected clients.
+func TestHandler_WS_PushesEvents(t *testing.T) {
+	setupMockActor()
+	eventBroker = events.NewBroker(10)
+	conn := dialWS(t)
+
+	// a round trip guarantees the handler has subscribed before publishing
+	sendWS(t, conn, wsCommand{Action: wsActionList})
+	eventBroker.Publish(events.ItemUpdated, storage.Item{ID: 1, Description: "Pushed"})
+
+	message := readWS(t, conn)
+	if message.Type != "event" || message.Event == nil || message.Event.Item.Description != "Pushed" {
+		t.Errorf("unexpected message: %+v", message)
+	}
+}
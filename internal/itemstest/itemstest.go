@@ -0,0 +1,106 @@
+// Package itemstest provides an in-memory item service for testing the command line, shell and terminal UI
+// without a data file or server.
+package itemstest
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+	"todo-app/storage"
+)
+
+// Created is the creation time of items made with Create, fixed so rendered output is stable.
+var Created = time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+// Fake has the actor's item methods over an in-memory list, returning the same errors as storage.
+type Fake struct {
+	// Items is the list, keyed by ID; tests may read and change it directly.
+	Items storage.Items
+}
+
+// New returns a fake holding the items.
+func New(items ...storage.Item) *Fake {
+	f := &Fake{Items: storage.Items{}}
+	for _, item := range items {
+		f.Items[item.ID] = item
+	}
+	return f
+}
+
+// Create adds an item under the next ID after the highest one, as storage does.
+func (f *Fake) Create(ctx context.Context, description string, status string) (storage.Item, error) {
+	if status == "" {
+		status = storage.Statuses[0]
+	}
+	if err := validate(description, status); err != nil {
+		return storage.Item{}, err
+	}
+	id := 1
+	if len(f.Items) > 0 {
+		id = slices.Max(slices.Collect(maps.Keys(f.Items))) + 1
+	}
+	item := storage.Item{ID: id, Description: description, Status: status, Created: Created}
+	f.Items[id] = item
+	return item, nil
+}
+
+// Update changes the description and status of an item.
+func (f *Fake) Update(ctx context.Context, id int, description string, status string) (storage.Item, error) {
+	item, ok := f.Items[id]
+	if !ok {
+		return storage.Item{}, storage.ErrItemNotFound
+	}
+	if err := validate(description, status); err != nil {
+		return storage.Item{}, err
+	}
+	item.Description, item.Status = description, status
+	f.Items[id] = item
+	return item, nil
+}
+
+// Delete removes an item.
+func (f *Fake) Delete(ctx context.Context, id int) error {
+	if _, ok := f.Items[id]; !ok {
+		return storage.ErrItemNotFound
+	}
+	delete(f.Items, id)
+	return nil
+}
+
+// Restore puts an item back under its ID unless the ID is in use.
+func (f *Fake) Restore(ctx context.Context, item storage.Item) (storage.Item, error) {
+	if _, ok := f.Items[item.ID]; ok {
+		return storage.Item{}, storage.ErrItemExists
+	}
+	f.Items[item.ID] = item
+	return item, nil
+}
+
+// ListAll returns a copy of the list, or storage.ErrNoItems when it is empty.
+func (f *Fake) ListAll(ctx context.Context) (storage.Items, error) {
+	if len(f.Items) == 0 {
+		return storage.Items{}, storage.ErrNoItems
+	}
+	return maps.Clone(f.Items), nil
+}
+
+// List returns one item.
+func (f *Fake) List(ctx context.Context, id int) (storage.Item, error) {
+	item, ok := f.Items[id]
+	if !ok {
+		return storage.Item{}, storage.ErrItemNotFound
+	}
+	return item, nil
+}
+
+// validate rejects what storage rejects.
+func validate(description string, status string) error {
+	if description == "" {
+		return storage.ErrEmptyDescription
+	}
+	if !storage.ValidStatus(status) {
+		return storage.ErrInvalidStatus
+	}
+	return nil
+}
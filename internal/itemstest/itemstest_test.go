@@ -0,0 +1,46 @@
+package itemstest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo-app/storage"
+)
+
+// TestItemstest_Fake tests that the fake numbers, changes and restores items and returns the storage errors.
+func TestItemstest_Fake(t *testing.T) {
+	ctx := context.Background()
+	f := New()
+	if _, err := f.ListAll(ctx); !errors.Is(err, storage.ErrNoItems) {
+		t.Errorf("expected ErrNoItems for an empty list, got %v", err)
+	}
+	f.Items[7] = storage.Item{ID: 7, Description: "Existing", Status: "in_progress"}
+	item, err := f.Create(ctx, "New", "")
+	if err != nil || item.ID != 8 || item.Status != "not_started" || !item.Created.Equal(Created) {
+		t.Fatalf("Create = %+v, %v", item, err)
+	}
+	if _, err := f.Create(ctx, "", ""); !errors.Is(err, storage.ErrEmptyDescription) {
+		t.Errorf("expected ErrEmptyDescription, got %v", err)
+	}
+	if _, err := f.Update(ctx, 8, "New", "done"); !errors.Is(err, storage.ErrInvalidStatus) {
+		t.Errorf("expected ErrInvalidStatus, got %v", err)
+	}
+	if err := f.Delete(ctx, 8); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := f.List(ctx, 8); !errors.Is(err, storage.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound after Delete, got %v", err)
+	}
+	if _, err := f.Restore(ctx, item); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if _, err := f.Restore(ctx, item); !errors.Is(err, storage.ErrItemExists) {
+		t.Errorf("expected ErrItemExists, got %v", err)
+	}
+
+	list, _ := f.ListAll(ctx)
+	delete(list, 7)
+	if _, ok := f.Items[7]; !ok {
+		t.Error("expected ListAll to return a copy")
+	}
+}
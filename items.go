@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"todo-app/actor"
+	"todo-app/client"
+	"todo-app/render"
+	"todo-app/shell"
+	"todo-app/storage"
+	"todo-app/tui"
+)
+
+const (
+	// remoteEnv sets the server for -remote when the flag is not given.
+	remoteEnv string = "TODO_REMOTE"
+	// tokenEnv holds the API token for -remote, kept out of the command line so it does not show up in ps.
+	tokenEnv string = "TODO_TOKEN"
+)
+
+// itemService is the part of the actor's method set the item commands use.
+// It is served by an actor over the data file, or by client.Client against a running server.
+type itemService interface {
+	Create(ctx context.Context, description string, status string) (storage.Item, error)
+	Update(ctx context.Context, id int, description string, status string) (storage.Item, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, item storage.Item) (storage.Item, error)
+	ListAll(ctx context.Context) (storage.Items, error)
+	List(ctx context.Context, id int) (storage.Item, error)
+}
+
+// itemFlags are the flags every item command has.
+type itemFlags struct {
+	remote *string
+	output *string
+}
+
+// openItems is how item commands reach the items; tests replace it with an in-memory service.
+var openItems = func(c *cli, flags itemFlags) (itemService, error) {
+	// a running server owns the data file, so send the command to it
+	if *flags.remote != "" {
+		slog.InfoContext(c.ctx, "Running remote command", "server", *flags.remote)
+		return client.New(*flags.remote, os.Getenv(tokenEnv))
+	}
+	storagefile := c.path(c.settings.Data.ItemsFile)
+	if err := storage.Open(c.ctx, storagefile); err != nil {
+		return nil, fmt.Errorf("open data file %s: %w", storagefile, err)
+	}
+	a := actor.NewActor(c.ctx)
+	c.closers = append(c.closers, func() { a.Stop(context.WithoutCancel(c.ctx)) })
+	return a, nil
+}
+
+// newItemFlags defines the flags shared by the item commands.
+func newItemFlags(fs *flag.FlagSet) itemFlags {
+	return itemFlags{
+		remote: remoteFlag(fs),
+		output: fs.String("output", render.Table, "output format ("+strings.Join(render.Formats, "|")+")"),
+	}
+}
+
+// remoteFlag defines the -remote flag of the commands that work on items.
+func remoteFlag(fs *flag.FlagSet) *string {
+	return fs.String("remote", os.Getenv(remoteEnv), "run against the server at this URL (\"http://host:8080\") instead of the data file, with the API token in $"+tokenEnv+" (default $"+remoteEnv+")")
+}
+
+// renderer returns the renderer for -output, checked before anything is opened.
+func (f itemFlags) renderer() (render.Renderer, error) {
+	r, err := render.New(*f.output)
+	if err != nil {
+		return nil, usageError(err.Error())
+	}
+	return r, nil
+}
+
+// statusFlag defines the -status flag of the item commands.
+func statusFlag(fs *flag.FlagSet, usage string) *string {
+	return fs.String("status", "", usage+" ("+strings.Join(storage.Statuses, "|")+")")
+}
+
+// addCommand creates an item.
+func addCommand() command {
+	return command{name: "add", args: "<description>", summary: "Create an item", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		flags := newItemFlags(fs)
+		status := statusFlag(fs, "status of the new item, default not_started")
+		maxDescription := maxDescriptionFlag(fs)
+		return func(c *cli, args []string) error {
+			description := strings.Join(args, " ")
+			if description == "" {
+				return usageError("add needs a description")
+			}
+			if err := checkStatus(*status); err != nil {
+				return err
+			}
+			r, err := flags.renderer()
+			if err != nil {
+				return err
+			}
+			storage.SetMaxDescriptionLength(*maxDescription)
+			items, err := openItems(c, flags)
+			if err != nil {
+				return err
+			}
+			item, err := items.Create(c.ctx, description, *status)
+			if err != nil {
+				return fmt.Errorf("create item: %w", err)
+			}
+			return r.Item(c.stdout, item)
+		}
+	}}
+}
+
+// listCommand lists the items.
+func listCommand() command {
+	return command{name: "list", summary: "List all items", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		flags := newItemFlags(fs)
+		status := statusFlag(fs, "only list items with this status")
+		return func(c *cli, args []string) error {
+			if len(args) > 0 {
+				return usageError("list takes no arguments, use get <id> for one item")
+			}
+			if err := checkStatus(*status); err != nil {
+				return err
+			}
+			r, err := flags.renderer()
+			if err != nil {
+				return err
+			}
+			items, err := openItems(c, flags)
+			if err != nil {
+				return err
+			}
+			return writeAllItems(c, items, r, *status)
+		}
+	}}
+}
+
+// getCommand shows one item.
+func getCommand() command {
+	return command{name: "get", args: "<id>", summary: "Show an item", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		flags := newItemFlags(fs)
+		return func(c *cli, args []string) error {
+			id, err := itemID("get", args)
+			if err != nil {
+				return err
+			}
+			r, err := flags.renderer()
+			if err != nil {
+				return err
+			}
+			items, err := openItems(c, flags)
+			if err != nil {
+				return err
+			}
+			item, err := items.List(c.ctx, id)
+			if err != nil {
+				return fmt.Errorf("get item %d: %w", id, err)
+			}
+			return r.Item(c.stdout, item)
+		}
+	}}
+}
+
+// doneCommand marks an item as finished.
+func doneCommand() command {
+	return command{name: "done", args: "<id>", summary: "Mark an item as finished", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		flags := newItemFlags(fs)
+		return func(c *cli, args []string) error {
+			id, err := itemID("done", args)
+			if err != nil {
+				return err
+			}
+			r, err := flags.renderer()
+			if err != nil {
+				return err
+			}
+			items, err := openItems(c, flags)
+			if err != nil {
+				return err
+			}
+			return updateItem(c, items, r, id, "", "is_finished")
+		}
+	}}
+}
+
+// editCommand changes the description or status of an item.
+func editCommand() command {
+	return command{name: "edit", args: "<id> [new description]",
+		summary: "Change the description or status of an item\nThe description and status are kept unless new ones are given.",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			flags := newItemFlags(fs)
+			status := statusFlag(fs, "new status")
+			maxDescription := maxDescriptionFlag(fs)
+			return func(c *cli, args []string) error {
+				if len(args) == 0 {
+					return usageError("edit needs an item ID")
+				}
+				id, err := itemID("edit", args[:1])
+				if err != nil {
+					return err
+				}
+				description := strings.Join(args[1:], " ")
+				if description == "" && *status == "" {
+					return usageError("edit needs a new description or -status")
+				}
+				if err := checkStatus(*status); err != nil {
+					return err
+				}
+				r, err := flags.renderer()
+				if err != nil {
+					return err
+				}
+				storage.SetMaxDescriptionLength(*maxDescription)
+				items, err := openItems(c, flags)
+				if err != nil {
+					return err
+				}
+				return updateItem(c, items, r, id, description, *status)
+			}
+		}}
+}
+
+// rmCommand deletes an item.
+func rmCommand() command {
+	return command{name: "rm", args: "<id>", summary: "Delete an item and list the remaining items", setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+		flags := newItemFlags(fs)
+		return func(c *cli, args []string) error {
+			id, err := itemID("rm", args)
+			if err != nil {
+				return err
+			}
+			r, err := flags.renderer()
+			if err != nil {
+				return err
+			}
+			items, err := openItems(c, flags)
+			if err != nil {
+				return err
+			}
+			if err := items.Delete(c.ctx, id); err != nil {
+				return fmt.Errorf("delete item %d: %w", id, err)
+			}
+			return writeAllItems(c, items, r, "")
+		}
+	}}
+}
+
+// tuiCommand opens the full screen terminal interface.
+func tuiCommand() command {
+	return command{name: "tui",
+		summary: "Browse and change items in a full screen terminal interface\nKeys: up/down or j/k move, space cycles the status, e or Enter edits the description,\ns filters by status, t filters by #tag, r reloads and q quits.",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			flags := itemFlags{remote: remoteFlag(fs)}
+			return func(c *cli, args []string) error {
+				if len(args) > 0 {
+					return usageError("tui takes no arguments")
+				}
+				items, err := openItems(c, flags)
+				if err != nil {
+					return err
+				}
+				return tui.Run(c.ctx, items, os.Stdin, os.Stdout)
+			}
+		}}
+}
+
+// shellCommand runs item commands one line at a time.
+func shellCommand() command {
+	return command{name: "shell",
+		summary: "Run item commands in an interactive shell that keeps the data file open\nCommands are add, ls, done, edit, rm, undo, help and exit. On a terminal, lines have history\nand Tab completes commands and item IDs; otherwise commands are read from stdin one per line.",
+		setup: func(fs *flag.FlagSet) func(c *cli, args []string) error {
+			flags := itemFlags{remote: remoteFlag(fs)}
+			return func(c *cli, args []string) error {
+				if len(args) > 0 {
+					return usageError("shell takes no arguments")
+				}
+				items, err := openItems(c, flags)
+				if err != nil {
+					return err
+				}
+				return shell.Run(c.ctx, items, os.Stdin, os.Stdout, c.path(historyfile))
+			}
+		}}
+}
+
+// updateItem changes an item, keeping its current description or status where the new one is empty.
+func updateItem(c *cli, items itemService, r render.Renderer, id int, description string, status string) error {
+	current, err := items.List(c.ctx, id)
+	if err != nil {
+		return fmt.Errorf("update item %d: %w", id, err)
+	}
+	changed := current.Changed(description, status)
+	item, err := items.Update(c.ctx, id, changed.Description, changed.Status)
+	if err != nil {
+		return fmt.Errorf("update item %d: %w", id, err)
+	}
+	return r.Item(c.stdout, item)
+}
+
+// writeAllItems lists the items, optionally only those with a status; an empty list is not an error.
+func writeAllItems(c *cli, items itemService, r render.Renderer, status string) error {
+	list, err := items.ListAll(c.ctx)
+	if err != nil && !errors.Is(err, storage.ErrNoItems) {
+		return fmt.Errorf("list items: %w", err)
+	}
+	return r.List(c.stdout, list.WithStatus(status))
+}
+
+// itemID parses the single item ID argument of a command.
+func itemID(name string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, usageError(name + " needs one item ID")
+	}
+	id, err := storage.ParseID(args[0])
+	if err != nil {
+		return 0, usageError(err.Error())
+	}
+	return id, nil
+}
+
+// checkStatus rejects an unknown -status value before anything is opened.
+func checkStatus(status string) error {
+	if status != "" && !storage.ValidStatus(status) {
+		return usageError(fmt.Sprintf("invalid status %q, use %s", status, strings.Join(storage.Statuses, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-app/internal/itemstest"
+	"todo-app/storage"
+)
+
+// useFakeItems makes the item commands use the fake for the rest of the test.
+func useFakeItems(t *testing.T, fake *itemstest.Fake) {
+	original := openItems
+	openItems = func(c *cli, flags itemFlags) (itemService, error) {
+		return fake, nil
+	}
+	t.Cleanup(func() { openItems = original })
+}
+
+// TestMain_ItemCommands tests the item commands and their output.
+func TestMain_ItemCommands(t *testing.T) {
+	setupDataFolder(t)
+	fake := itemstest.New(storage.Item{ID: 1, Description: "Buy milk", Status: "in_progress"})
+	useFakeItems(t, fake)
+
+	tests := []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"add", "Walk", "the", "dog", "-status", "in_progress"}, exitOK, "2\tin_progress\tWalk the dog", ""},
+		{[]string{"get", "2"}, exitOK, "2\tin_progress\tWalk the dog", ""},
+		{[]string{"edit", "1", "Buy", "oat", "milk"}, exitOK, "1\tin_progress\tBuy oat milk", ""},
+		{[]string{"edit", "1", "-status", "not_started"}, exitOK, "1\tnot_started\tBuy oat milk", ""},
+		{[]string{"done", "2"}, exitOK, "2\tis_finished\tWalk the dog", ""},
+		{[]string{"list", "-status", "is_finished"}, exitOK, "2\tis_finished", ""},
+		{[]string{"get", "9"}, exitFailed, "", "Error: get item 9: item not found"},
+		{[]string{"edit", "1"}, exitUsage, "", "edit needs a new description or -status"},
+		{[]string{"rm", "2"}, exitOK, "1\tnot_started\tBuy oat milk", ""},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runCLI(t, tt.args...)
+		if code != tt.code || !strings.Contains(stdout, tt.stdout) || !strings.Contains(stderr, tt.stderr) {
+			t.Errorf("%q: got exit %d\nstdout: %s\nstderr: %s", tt.args, code, stdout, stderr)
+		}
+	}
+	if _, stdout, _ := runCLI(t, "list", "-status", "is_finished"); strings.Contains(stdout, "Walk the dog") {
+		t.Errorf("expected the deleted item to be gone:\n%s", stdout)
+	}
+}
+
+// TestMain_ItemCommandsLocal tests the item commands against the data file.
+func TestMain_ItemCommandsLocal(t *testing.T) {
+	setupDataFolder(t)
+	if code, _, stderr := runCLI(t, "add", "Local item"); code != exitOK {
+		t.Fatalf("add failed: %d %s", code, stderr)
+	}
+	if code, _, stderr := runCLI(t, "done", "1"); code != exitOK {
+		t.Fatalf("done failed: %d %s", code, stderr)
+	}
+	code, stdout, _ := runCLI(t, "list")
+	if code != exitOK || !strings.Contains(stdout, "1\tis_finished\tLocal item") {
+		t.Errorf("expected the finished item in the data file, got %d\n%s", code, stdout)
+	}
+
+	// storage logs go to the log file, so stdout holds only the output
+	if _, stdout, _ := runCLI(t, "add", "Second item", "-output", "ids"); stdout != "2\n" {
+		t.Errorf("expected only the new ID on stdout, got %q", stdout)
+	}
+}
+
+// TestMain_ItemCommandsOutput tests the -output formats of the item commands.
+func TestMain_ItemCommandsOutput(t *testing.T) {
+	setupDataFolder(t)
+	useFakeItems(t, itemstest.New(
+		storage.Item{ID: 1, Description: "Buy milk", Status: "in_progress"},
+		storage.Item{ID: 2, Description: "Walk the dog", Status: "is_finished"},
+	))
+
+	_, stdout, _ := runCLI(t, "list", "-output", "json")
+	var items []storage.Item
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil || len(items) != 2 {
+		t.Errorf("expected a JSON array of 2 items, got %v\n%s", err, stdout)
+	}
+	tests := []struct {
+		args   []string
+		stdout string
+	}{
+		{[]string{"list", "-output", "ids", "-status", "in_progress"}, "1\n"},
+		{[]string{"get", "2", "-output", "csv"}, "id,status,description,created,owner\n2,is_finished,Walk the dog,0001-01-01T00:00:00Z,\n"},
+		{[]string{"done", "1", "-output", "yaml"}, "id: 1\ndescription: \"Buy milk\"\nstatus: is_finished\ncreated: 0001-01-01T00:00:00Z\n"},
+		{[]string{"add", "Feed the cat", "-output", "ids"}, "3\n"},
+	}
+	for _, tt := range tests {
+		if code, stdout, stderr := runCLI(t, tt.args...); code != exitOK || stdout != tt.stdout {
+			t.Errorf("%q: got exit %d\nstdout: %q\nstderr: %s", tt.args, code, stdout, stderr)
+		}
+	}
+	if code, _, stderr := runCLI(t, "list", "-output", "xml"); code != exitUsage || !strings.Contains(stderr, `unknown output format "xml"`) {
+		t.Errorf("expected a usage error for an unknown format, got %d %s", code, stderr)
+	}
+}
+
+// TestMain_ItemCommandsRemote tests that -remote sends item commands to the server with the token.
+func TestMain_ItemCommandsRemote(t *testing.T) {
+	setupDataFolder(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/get" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"id":7,"description":"Remote","status":"is_finished","created":"2026-10-18T09:00:00Z"}]`))
+	}))
+	defer server.Close()
+
+	t.Setenv(tokenEnv, "secret")
+	code, stdout, stderr := runCLI(t, "list", "-remote", server.URL)
+	if code != exitOK || !strings.Contains(stdout, "7\tis_finished\tRemote") {
+		t.Errorf("remote list failed: %d\n%s%s", code, stdout, stderr)
+	}
+
+	// the server can also come from the environment
+	t.Setenv(remoteEnv, server.URL)
+	t.Setenv(tokenEnv, "wrong")
+	if code, _, stderr := runCLI(t, "list"); code != exitFailed || !strings.Contains(stderr, "Unauthorized") {
+		t.Errorf("expected the rejected token to fail, got %d %s", code, stderr)
+	}
+}
@@ -1,13 +1,34 @@
 package logging
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
+// ContextKey is the type of context keys read by the logger.
+type ContextKey string
+
+// TraceIDKey holds the trace ID added to every log record written with the context.
+const TraceIDKey ContextKey = "Trace ID"
+
+// WithTraceID returns a copy of ctx carrying the trace ID.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, TraceIDKey, traceID)
+}
+
+// TraceID returns the trace ID carried by ctx.
+func TraceID(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(TraceIDKey).(string)
+	return traceID, ok
+}
+
 // GenerateID returns a random 16-byte hex string (32 hex chars).
 // We use crypto/rand for strong uniqueness properties.
 func GenerateID() string {
@@ -19,14 +40,18 @@ func GenerateID() string {
 	return hex.EncodeToString(b[:])
 }
 
-// CreateAppDataFolder creates an application data folder in the user's cache directory.
+// CreateAppDataFolder creates an application data folder in the user's cache directory,
+// or at applicationName itself when that is an absolute path.
 func CreateAppDataFolder(applicationName string) (string, error) {
-	dir, err := os.UserCacheDir()
-	if err != nil {
-		return "", err
+	dir := applicationName
+	if !filepath.IsAbs(dir) {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = cacheDir + "\\" + applicationName
 	}
-	dir = dir + "\\" + applicationName
-	err = os.MkdirAll(dir, 0600)
+	err := os.MkdirAll(dir, 0600)
 	if err != nil {
 		return "", err
 	}
@@ -48,9 +73,25 @@ func OpenLogFile(fileName string) (*os.File, error) {
 	return fi, nil
 }
 
-// LoggerOptions returns slog HandlerOptions suitable for the current environment.
-func LoggerOptions() slog.HandlerOptions {
-	// TODO: adjust options based on environment
-	options := slog.HandlerOptions{AddSource: false}
-	return options
+// LogFormats are the log record formats accepted by NewHandler.
+var LogFormats = []string{"text", "json"}
+
+// LoggerOptions returns the slog HandlerOptions for a level name (debug, info, warn or error) and whether to add the source line.
+func LoggerOptions(level string, addSource bool) (slog.HandlerOptions, error) {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return slog.HandlerOptions{}, fmt.Errorf("log level %q: use debug, info, warn or error", level)
+	}
+	return slog.HandlerOptions{Level: logLevel, AddSource: addSource}, nil
+}
+
+// NewHandler returns a handler writing records to w in one of LogFormats.
+func NewHandler(w io.Writer, format string, options slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case "text":
+		return slog.NewTextHandler(w, &options), nil
+	case "json":
+		return slog.NewJSONHandler(w, &options), nil
+	}
+	return nil, fmt.Errorf("log format %q: use %s", format, strings.Join(LogFormats, " or "))
 }
@@ -1,7 +1,11 @@
 package logging
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -42,8 +46,42 @@ func TestLogging_OpenLogFile(t *testing.T) {
 
 // TestLogging_LoggerOptions checks the LoggerOptions function.
 func TestLogging_LoggerOptions(t *testing.T) {
-	opts := LoggerOptions()
-	if opts.AddSource {
-		t.Error("Expected AddSource to be false")
+	opts, err := LoggerOptions("info", false)
+	if err != nil || opts.AddSource {
+		t.Errorf("Expected AddSource to be false, got %+v, %v", opts, err)
+	}
+	opts, err = LoggerOptions("debug", true)
+	if err != nil || !opts.AddSource || opts.Level != slog.LevelDebug {
+		t.Errorf("Expected debug with source, got %+v, %v", opts, err)
+	}
+	if _, err := LoggerOptions("loud", false); err == nil {
+		t.Error("Expected error for an unknown level")
+	}
+}
+
+// TestLogging_NewHandler checks the text and json record formats.
+func TestLogging_NewHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, "json", slog.HandlerOptions{})
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	slog.New(handler).Info("hello")
+	if !strings.HasPrefix(buf.String(), `{"time":`) {
+		t.Errorf("Expected a json record, got %q", buf.String())
+	}
+	if _, err := NewHandler(&buf, "xml", slog.HandlerOptions{}); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
+
+// TestLogging_TraceID checks that a trace ID stored in a context can be read back.
+func TestLogging_TraceID(t *testing.T) {
+	if _, ok := TraceID(context.Background()); ok {
+		t.Error("Expected no trace ID in an empty context")
+	}
+	ctx := WithTraceID(context.Background(), "trace-1")
+	if traceID, ok := TraceID(ctx); !ok || traceID != "trace-1" {
+		t.Errorf("Expected trace ID 'trace-1', got '%s'", traceID)
 	}
 }
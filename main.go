@@ -2,22 +2,39 @@ package main
 
 import (
 	"context"
-	"flag"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"todo-app/assets"
+	"todo-app/auth"
+	"todo-app/config"
 	"todo-app/handler"
 	"todo-app/logging"
 	"todo-app/storage"
+	"todo-app/tlscert"
 )
 
+// The data folder and the files in it are settings, see config.Data; these names are fixed.
 const (
-	datafolder string = "tododata"
-	datafile   string = "todos.json"
-	logfile    string = "todos.log"
+	configfolder string = "todo-app"
+	configfile   string = "config.json"
+	certfile     string = "cert.pem"
+	keyfile      string = "key.pem"
+	historyfile  string = "shell_history"
 )
 
+// configEnv names the config file when -config is not given.
+const configEnv string = "TODO_CONFIG"
+
+// shutdownTimeout bounds how long the server waits for open requests and queued actor commands on SIGINT or SIGTERM.
+const shutdownTimeout = 10 * time.Second
+
 type RunMode string
 
 const (
@@ -25,9 +42,8 @@ const (
 	RunModeServer = "SERVER"
 )
 
-type ctxKey string
-
-const traceIDKey ctxKey = "Trace ID"
+// traceIDKey is shared with the handler package, which sets a trace ID for every request.
+const traceIDKey = logging.TraceIDKey
 
 type ContextHandler struct {
 	slog.Handler
@@ -46,154 +62,177 @@ func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
 }
 
 func main() {
-	// default to cli mode
+	// default to cli mode, the serve command switches to server mode
 	runMode = RunModeCLI
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	// input flags
-	var flagCreate = flag.String("create", "", "create todo task item (\"description\") (optionally use -status \"not_started|in_progress|is_finished\")")
-	var flagUpdate = flag.Int("update", 0, "update todo task item description (id -description \"new description\") (optionally use -status \"not_started|in_progress|is_finished\")")
-	var flagDelete = flag.Int("delete", 0, "delete a todo task item ( id )")
-	var flagList = flag.Bool("list", false, "list items in the todo list ( optionally use -itemid num to show one item)")
-	var flagStatus = flag.String("status", "", "use this with -create or -update to set the status (\"not_started|in_progress|is_finished\")")
-	var flagDescription = flag.String("description", "", "use this with -update for the update description text -description \"new text\"")
-	var flagItemID = flag.Int("itemid", 0, "optional, use this -itemid with -list for one item")
-	var flagServer = flag.Bool("server", false, "run in server mode (starts HTTP API server)")
-	flag.Parse()
-
-	// setup application context with trace id
-	traceID := logging.GenerateID()
-	ctx := context.WithValue(context.Background(), traceIDKey, traceID)
-
-	// resolve the appdata data sub folder
-	dir, err := logging.CreateAppDataFolder(datafolder)
+// startServer initializes the actor, sets up routes, and serves HTTP until SIGINT or SIGTERM.
+// On a signal it stops taking requests, drains the actor and saves the data file; errors are already reported when returned.
+func startServer(ctx context.Context, dir string, assetsDir string, limits handler.Limits, data config.Data, cfg config.Server) error {
+	// Load templates and static files, embedded unless overridden for development
+	fsys, err := assets.FS(assetsDir)
+	if err == nil {
+		err = handler.InitAssets(fsys, assetsDir != "")
+	}
 	if err != nil {
-		// don't have a file logger yet!
-		fmt.Printf("Cannot establish working data folder")
-		return
-	}
-
-	// wire up logger
-	logName := dir + "\\" + logfile
-	if logFileHandle, err := logging.OpenLogFile(logName); err == nil {
-		defer logFileHandle.Close()
-		logOptions := logging.LoggerOptions()
-		slog.SetDefault(slog.New(&ContextHandler{slog.NewTextHandler(logFileHandle, &logOptions)}))
-		slog.InfoContext(ctx, "Starting up logging with static logger")
-	}
-
-	// init / pickup current list before process command
-	storagefile := fmt.Sprintf("%s\\%s", dir, datafile)
-
-	// open the data file for cli and api
-	openErr := storage.Open(ctx, storagefile)
-	if openErr != nil {
-		// log file not ready so default std.err logging here
-		slog.ErrorContext(ctx, "Open file failed, cannot continue", "error", openErr, "datafile", storagefile)
-		fmt.Printf("Open file failed, cannot continue,"+" error: %s, datafile: %s\n", openErr, storagefile)
-		return
-	}
-
-	// process the flags
-	switch {
-	case *flagList:
-		// list items (all if itemid is 0, or single item by id)
-		storage.ListItem(*flagItemID)
-	case *flagCreate != "":
-		if *flagStatus != "" {
-			if *flagStatus == "not_started" || *flagStatus == "in_progress" || *flagStatus == "is_finished" {
-				// valid status
-			} else {
-				fmt.Fprintf(os.Stderr, "Invalid status value: %s. Use 'not_started', 'in_progress', or 'is_finished'.\n", *flagStatus)
-				slog.ErrorContext(ctx, "Invalid status value for create", "Status", *flagStatus)
-				*flagStatus = "not_started"
-			}
-		}
-		if newItem, ok := storage.CreateItem(ctx, *flagCreate, *flagStatus); ok == nil {
-			storage.ListItem(newItem.ID)
-		} else {
-			fmt.Fprintf(os.Stderr, "Failed to create item.\n")
-			slog.ErrorContext(ctx, "Failed to create item", "Description", *flagCreate, "Status", *flagStatus)
-		}
-	case *flagUpdate > 0:
-		// validate description is not missing
-		if *flagDescription == "" {
-			fmt.Fprintf(os.Stderr, "Update requires -description \"new description\" to be set.\n")
-			slog.ErrorContext(ctx, "Update missing description", "ItemID", *flagUpdate)
-			break
-		}
+		fmt.Fprintf(os.Stderr, "Assets failed to load: %v\n", err)
+		slog.ErrorContext(ctx, "Assets failed to load", "error", err, "dir", assetsDir)
+		return err
+	}
 
-		// get existing item
-		if item, ok := storage.GetItemByID(*flagUpdate); ok == nil {
-			newItem := item
-			newItem.Description = *flagDescription
-			if *flagStatus == "not_started" || *flagStatus == "in_progress" || *flagStatus == "is_finished" {
-				newItem.Status = *flagStatus
-			} else {
-				fmt.Fprintf(os.Stderr, "Invalid status value: %s. Use 'not_started', 'in_progress', or 'is_finished'.\n", *flagStatus)
-				slog.ErrorContext(ctx, "Invalid status value for update", "Status", *flagStatus)
-				break
-			}
-
-			// perform the update
-			if _, ok := storage.UpdateItem(ctx, newItem); ok == nil {
-				storage.ListItem(*flagUpdate)
-			} else {
-				fmt.Fprintf(os.Stderr, "Failed to update item ID %d.\n", *flagUpdate)
-				slog.ErrorContext(ctx, "Failed to update item", "ItemID", *flagUpdate)
-			}
-		} else {
-			fmt.Fprintf(os.Stderr, "Item ID %d not found for update.\n", *flagUpdate)
-			slog.ErrorContext(ctx, "Item ID not found for update", "ItemID", *flagUpdate)
-		}
-	case *flagDelete > 0:
-		// perform delete
-		if ok := storage.DeleteItem(ctx, *flagDelete); ok == nil {
-			storage.ListItem(0)
-		} else {
-			fmt.Fprintf(os.Stderr, "Item ID %d not found for delete.\n", *flagDelete)
-			slog.ErrorContext(ctx, "Item ID not found for delete", "ItemID", *flagDelete)
-		}
-	case *flagServer:
-		// start server mode
-		runMode = RunModeServer
-	default:
-		// print usage
-		fmt.Fprintf(os.Stderr, `Todo-App
-Manage to-do items: list, add, update descriptions, or delete by ID.
-
-Usage:
-  go run . -list [-itemid <id>] (list all items or one item by ID)
-  go run . -create "<description> " [-status "not_started|in_progress|is_finished"] (create new item)
-  go run . -update <id> "<new description> " [-status "not_started|in_progress|is_finished"] (update item)
-  go run . -delete <id> (delete item by ID)
-  go run . -server true (to start HTTP API server)
-`)
-	}
-
-	if runMode == RunModeCLI {
-		// write back to the file
-		storage.Save(ctx, storagefile)
-	} else {
-		// start server mode
-		slog.InfoContext(ctx, "Starting server mode")
-		fmt.Println("Starting server mode on http://localhost:8080")
-		startServer(ctx)
+	// Load the API tokens and web UI users, the server refuses requests until one is created
+	store, err := auth.OpenTokenStore(dir + "\\" + data.TokensFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Tokens failed to load: %v\n", err)
+		slog.ErrorContext(ctx, "Tokens failed to load", "error", err)
+		return err
 	}
-}
+	users, err := auth.OpenUserStore(dir + "\\" + data.UsersFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Users failed to load: %v\n", err)
+		slog.ErrorContext(ctx, "Users failed to load", "error", err)
+		return err
+	}
+	handler.InitAuth(store, users)
 
-// startServer initializes the actor, sets up routes, and starts the HTTP server
-func startServer(ctx context.Context) {
-	// Initialize actor
-	handler.InitActor(ctx)
+	// Initialize actor with the shared lists
+	shares, err := auth.OpenShareStore(dir + "\\" + data.SharesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Shares failed to load: %v\n", err)
+		slog.ErrorContext(ctx, "Shares failed to load", "error", err)
+		return err
+	}
+	handler.InitActor(ctx, shares)
+
+	// Initialize webhook delivery from the data folder
+	if err := handler.InitWebhooks(ctx, dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Webhooks failed to load: %v\n", err)
+		slog.ErrorContext(ctx, "Webhooks failed to load", "error", err)
+		return err
+	}
+
+	// Throttle clients and cap request sizes
+	handler.InitLimits(limits)
 
 	// Setup HTTP routes
 	mux := http.NewServeMux()
 	handler.AddRoutes(mux)
 
+	// Use a generated certificate for local HTTPS
+	if cfg.TLSSelfSigned {
+		cfg.TLSCert, cfg.TLSKey = dir+"\\"+certfile, dir+"\\"+keyfile
+		if err := tlscert.EnsureSelfSigned(cfg.TLSCert, cfg.TLSKey, tlscert.LocalHosts); err != nil {
+			fmt.Fprintf(os.Stderr, "Self-signed certificate failed: %v\n", err)
+			slog.ErrorContext(ctx, "Self-signed certificate failed", "error", err)
+			return err
+		}
+	}
+
 	// Start HTTP server
-	err := http.ListenAndServe(":8080", mux)
+	server := newHTTPServer(cfg, handler.Wrap(mux))
+	listener, err := listen(cfg)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "Listen failed: %v\n", err)
+		slog.ErrorContext(ctx, "Listen failed", "error", err, "addr", cfg.Addr)
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Starting server mode on %s\n", serverURL(cfg))
+
+	// Serve until the listener fails or a signal asks us to stop
+	server.RegisterOnShutdown(handler.CloseStreams)
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.TLS() {
+			serveErr <- server.ServeTLS(listener, cfg.TLSCert, cfg.TLSKey)
+		} else {
+			serveErr <- server.Serve(listener)
+		}
+	}()
+	signals, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serveErr:
 		fmt.Fprintf(os.Stderr, "Server failed: %v\n", err)
 		slog.ErrorContext(ctx, "Server failed", "error", err)
+		return err
+	case <-signals.Done():
+		stopSignals()
+	}
+
+	// Stop accepting connections and wait for open requests, then drain the actor and webhooks
+	fmt.Fprintf(os.Stderr, "Shutting down\n")
+	slog.InfoContext(ctx, "Shutting down", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	if drainErr := handler.Shutdown(shutdownCtx); drainErr != nil {
+		err = errors.Join(err, drainErr)
+	} else {
+		// the actor goroutine has finished, so the items are safe to write back
+		err = errors.Join(err, storage.Save(ctx, storage.GetDataFile()))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Shutdown failed: %v\n", err)
+		slog.ErrorContext(ctx, "Shutdown failed", "error", err)
+		return err
+	}
+	slog.InfoContext(ctx, "Server stopped")
+	return nil
+}
+
+// newHTTPServer creates the HTTP server with the configured timeouts and header limit.
+func newHTTPServer(cfg config.Server, h http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           h,
+		ReadTimeout:       time.Duration(cfg.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(cfg.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.IdleTimeout),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// listen opens the TCP address, or the Unix domain socket for unix: addresses.
+// A socket left behind by a previous run is replaced, and the new one is only accessible to the current user
+// from the moment it is created.
+func listen(cfg config.Server) (net.Listener, error) {
+	path, ok := cfg.UnixSocket()
+	if !ok {
+		return net.Listen("tcp", cfg.Addr)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+	listener, err := listenSocket(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// serverURL describes where the server can be reached, for the startup message.
+func serverURL(cfg config.Server) string {
+	if path, ok := cfg.UnixSocket(); ok {
+		return "unix socket " + path
+	}
+	scheme := "http"
+	if cfg.TLS() {
+		scheme = "https"
+	}
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return scheme + "://" + cfg.Addr
+	}
+	if host == "" {
+		host = "localhost"
 	}
+	return scheme + "://" + net.JoinHostPort(host, port)
 }
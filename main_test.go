@@ -3,10 +3,16 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
+	"todo-app/config"
+	"todo-app/logging"
 )
 
 // TestMain_ContextHandler tests the ContextHandler Handle method.
@@ -287,37 +293,34 @@ func TestMain_ContextHandler_WithSourceOption(t *testing.T) {
 	}
 }
 
-// TestMain_ContextKey tests the context key type.
+// TestMain_ContextKey tests that the trace ID key is the one the logging package reads.
 func TestMain_ContextKey(t *testing.T) {
-	key1 := ctxKey("key1")
-	key2 := ctxKey("key2")
-
-	if key1 == key2 {
-		t.Error("Different context keys should not be equal")
+	if traceIDKey != logging.TraceIDKey {
+		t.Errorf("Expected traceIDKey to be logging.TraceIDKey, got %q", traceIDKey)
 	}
 
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, key1, "value1")
-	ctx = context.WithValue(ctx, key2, "value2")
+	ctx := context.WithValue(context.Background(), logging.TraceIDKey, "trace-1")
+	ctx = context.WithValue(ctx, logging.ContextKey("other"), "other value")
 
-	if ctx.Value(key1) != "value1" {
-		t.Error("Expected to retrieve value1 for key1")
+	if ctx.Value(logging.TraceIDKey) != "trace-1" {
+		t.Error("Expected to retrieve the trace ID for logging.TraceIDKey")
 	}
-	if ctx.Value(key2) != "value2" {
-		t.Error("Expected to retrieve value2 for key2")
+	if ctx.Value(logging.ContextKey("other")) != "other value" {
+		t.Error("Expected another key not to collide with logging.TraceIDKey")
 	}
 }
 
-// TestMain_Constants tests that constants are defined correctly.
+// TestMain_Constants tests that the default data folder and file names are kept.
 func TestMain_Constants(t *testing.T) {
-	if datafolder != "tododata" {
-		t.Errorf("Expected datafolder to be 'tododata', got '%s'", datafolder)
+	data := config.Default().Data
+	if data.Folder != "tododata" {
+		t.Errorf("Expected data folder to be 'tododata', got '%s'", data.Folder)
 	}
-	if datafile != "todos.json" {
-		t.Errorf("Expected datafile to be 'todos.json', got '%s'", datafile)
+	if data.ItemsFile != "todos.json" {
+		t.Errorf("Expected items file to be 'todos.json', got '%s'", data.ItemsFile)
 	}
-	if logfile != "todos.log" {
-		t.Errorf("Expected logfile to be 'todos.log', got '%s'", logfile)
+	if data.LogFile != "todos.log" {
+		t.Errorf("Expected log file to be 'todos.log', got '%s'", data.LogFile)
 	}
 }
 
@@ -365,3 +368,101 @@ func TestMain_RunModeVariable(t *testing.T) {
 		t.Errorf("Expected runMode to be 'SERVER', got '%s'", runMode)
 	}
 }
+
+// TestMain_ServerURL tests the startup message address.
+func TestMain_ServerURL(t *testing.T) {
+	cases := map[string]config.Server{
+		"http://localhost:8080":   {Addr: ":8080"},
+		"https://127.0.0.1:8443":  {Addr: "127.0.0.1:8443", TLSSelfSigned: true},
+		"unix socket /tmp/x.sock": {Addr: "unix:/tmp/x.sock"},
+	}
+	for want, cfg := range cases {
+		if got := serverURL(cfg); got != want {
+			t.Errorf("serverURL(%q) = %q, want %q", cfg.Addr, got, want)
+		}
+	}
+}
+
+// TestMain_NewHTTPServer tests that the configured timeouts and header limit are applied.
+func TestMain_NewHTTPServer(t *testing.T) {
+	cfg := config.Default().Server
+	server := newHTTPServer(cfg, http.NotFoundHandler())
+	if server.ReadHeaderTimeout != 5*time.Second || server.WriteTimeout != 30*time.Second || server.IdleTimeout != 120*time.Second {
+		t.Errorf("Unexpected timeouts: %+v", server)
+	}
+	if server.MaxHeaderBytes != cfg.MaxHeaderBytes {
+		t.Errorf("Expected MaxHeaderBytes %d, got %d", cfg.MaxHeaderBytes, server.MaxHeaderBytes)
+	}
+}
+
+// TestMain_ListenUnixSocket tests serving on a Unix domain socket that replaces a stale one.
+func TestMain_ListenUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "todo")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "todo.sock")
+	cfg := config.Server{Addr: config.UnixPrefix + path}
+
+	// a socket left behind by a crashed server
+	stale, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen over stale socket failed: %v", err)
+	}
+	defer listener.Close()
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected socket mode 0600, got %v", info.Mode().Perm())
+	}
+
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatalf("Get over unix socket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
+
+// TestMain_ListenSocketPrivate tests that the socket is created private to the current user, before listen chmods it.
+func TestMain_ListenSocketPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no umask on Windows")
+	}
+	// t.TempDir paths can be longer than a socket path may be
+	dir, err := os.MkdirTemp("", "todo")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "todo.sock")
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("listenSocket failed: %v", err)
+	}
+	defer listener.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		t.Errorf("Expected socket without group or other access, got %v", info.Mode().Perm())
+	}
+}
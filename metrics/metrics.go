@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the Prometheus text exposition format written by WriteText.
+const ContentType string = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are latency histogram bucket upper bounds in seconds, from 1ms to 10s.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default is the registry the packages of this module record into and /metrics serves.
+var Default = NewRegistry()
+
+// Registry holds a set of metrics and writes them in the Prometheus text exposition format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric is a counter, gauge or histogram family with its series.
+type metric interface {
+	write(w *bufio.Writer, name string)
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]metric{}}
+}
+
+// register adds a metric under a unique name; registering the same name twice is a programming error.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.metrics[name] = m
+}
+
+// WriteText writes every metric in the registry, ordered by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]metric, 0, len(names))
+	slices.Sort(names)
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for i, m := range metrics {
+		m.write(buf, names[i])
+	}
+	return buf.Flush()
+}
+
+// family holds the series of one metric, keyed by their label values.
+type family[S any] struct {
+	help   string
+	kind   string
+	labels []string
+	mu     sync.Mutex
+	series map[string]*labeled[S]
+	newS   func() *S
+}
+
+// labeled is one series and the label values that identify it.
+type labeled[S any] struct {
+	values []string
+	value  *S
+}
+
+// get returns the series for the label values, creating it on first use.
+func (f *family[S]) get(values []string) *S {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(values), f.labels))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &labeled[S]{values: slices.Clone(values), value: f.newS()}
+		f.series[key] = s
+	}
+	return s.value
+}
+
+// each calls fn for every series ordered by label values, holding the family lock.
+func (f *family[S]) each(fn func(values []string, value *S)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fn(f.series[key].values, f.series[key].value)
+	}
+}
+
+// header writes the HELP and TYPE lines of a metric.
+func (f *family[S]) header(w *bufio.Writer, name string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, f.kind)
+}
+
+// value is the current value of a counter or gauge series.
+type value struct {
+	v float64
+}
+
+// Counter is a metric that only goes up, with one series per combination of label values.
+type Counter struct {
+	family[value]
+}
+
+// NewCounter registers a counter with the given label names in the default registry.
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+// NewCounter registers a counter with the given label names.
+func (r *Registry) NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{family[value]{help: help, kind: "counter", labels: labels, series: map[string]*labeled[value]{}, newS: func() *value { return &value{} }}}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds a non-negative amount to the series with the given label values.
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	s := c.get(values)
+	c.mu.Lock()
+	s.v += delta
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer, name string) {
+	c.header(w, name)
+	c.each(func(values []string, s *value) {
+		writeSample(w, name, c.labels, values, "", "", s.v)
+	})
+}
+
+// Gauge is a metric that can go up and down, with one series per combination of label values.
+type Gauge struct {
+	family[value]
+}
+
+// NewGauge registers a gauge with the given label names in the default registry.
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return Default.NewGauge(name, help, labels...)
+}
+
+// NewGauge registers a gauge with the given label names.
+func (r *Registry) NewGauge(name string, help string, labels ...string) *Gauge {
+	g := &Gauge{family[value]{help: help, kind: "gauge", labels: labels, series: map[string]*labeled[value]{}, newS: func() *value { return &value{} }}}
+	r.register(name, g)
+	return g
+}
+
+// Set sets the series with the given label values.
+func (g *Gauge) Set(v float64, values ...string) {
+	s := g.get(values)
+	g.mu.Lock()
+	s.v = v
+	g.mu.Unlock()
+}
+
+// Add adds delta, which may be negative, to the series with the given label values.
+func (g *Gauge) Add(delta float64, values ...string) {
+	s := g.get(values)
+	g.mu.Lock()
+	s.v += delta
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w *bufio.Writer, name string) {
+	g.header(w, name)
+	g.each(func(values []string, s *value) {
+		writeSample(w, name, g.labels, values, "", "", s.v)
+	})
+}
+
+// buckets is the state of one histogram series.
+type buckets struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram counts observations into cumulative buckets, with one series per combination of label values.
+type Histogram struct {
+	family[buckets]
+	bounds []float64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and label names in the default registry.
+func NewHistogram(name string, help string, bounds []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, bounds, labels...)
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and label names.
+func (r *Registry) NewHistogram(name string, help string, bounds []float64, labels ...string) *Histogram {
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	h := &Histogram{bounds: bounds}
+	h.family = family[buckets]{help: help, kind: "histogram", labels: labels, series: map[string]*labeled[buckets]{},
+		newS: func() *buckets { return &buckets{counts: make([]uint64, len(bounds))} }}
+	r.register(name, h)
+	return h
+}
+
+// Observe records a value, such as a duration in seconds, in the series with the given label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	s := h.get(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer, name string) {
+	h.header(w, name)
+	h.each(func(values []string, s *buckets) {
+		for i, bound := range h.bounds {
+			writeSample(w, name+"_bucket", h.labels, values, "le", formatFloat(bound), float64(s.counts[i]))
+		}
+		writeSample(w, name+"_bucket", h.labels, values, "le", "+Inf", float64(s.count))
+		writeSample(w, name+"_sum", h.labels, values, "", "", s.sum)
+		writeSample(w, name+"_count", h.labels, values, "", "", float64(s.count))
+	})
+}
+
+// writeSample writes one sample line, with an optional extra label such as a histogram's le.
+func writeSample(w *bufio.Writer, name string, labels []string, values []string, extraLabel string, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, escapeLabel(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// formatFloat formats a sample value the way Prometheus parses it.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes backslashes and line breaks in HELP text.
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// escapeLabel escapes backslashes, line breaks and quotes in label values.
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestMetrics_CounterAndGauge tests the exposition of labeled counters and gauges.
+func TestMetrics_CounterAndGauge(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests served.", "route", "status")
+	items := r.NewGauge("items", "Items stored.")
+
+	requests.Inc("/get", "200")
+	requests.Inc("/get", "200")
+	requests.Add(3, "/create", "400")
+	items.Set(5)
+	items.Add(-2)
+
+	var out strings.Builder
+	if err := r.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	want := `# HELP items Items stored.
+# TYPE items gauge
+items 3
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/create",status="400"} 3
+requests_total{route="/get",status="200"} 2
+`
+	if out.String() != want {
+		t.Errorf("unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+// TestMetrics_Histogram tests cumulative buckets, sum and count.
+func TestMetrics_Histogram(t *testing.T) {
+	r := NewRegistry()
+	latency := r.NewHistogram("latency_seconds", "Latency.", []float64{0.5, 0.1}, "route")
+	latency.Observe(0.05, "/get")
+	latency.Observe(0.2, "/get")
+	latency.Observe(3, "/get")
+
+	var out strings.Builder
+	r.WriteText(&out)
+	for _, line := range []string{
+		"# TYPE latency_seconds histogram",
+		`latency_seconds_bucket{route="/get",le="0.1"} 1`,
+		`latency_seconds_bucket{route="/get",le="0.5"} 2`,
+		`latency_seconds_bucket{route="/get",le="+Inf"} 3`,
+		`latency_seconds_sum{route="/get"} 3.25`,
+		`latency_seconds_count{route="/get"} 3`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+// TestMetrics_Escaping tests that label values and help text are escaped.
+func TestMetrics_Escaping(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("escaped_total", "Line one\nline \\two", "path")
+	c.Inc("a\"b\\c\nd")
+
+	var out strings.Builder
+	r.WriteText(&out)
+	if !strings.Contains(out.String(), `# HELP escaped_total Line one\nline \\two`) {
+		t.Errorf("help not escaped:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), `escaped_total{path="a\"b\\c\nd"} 1`) {
+		t.Errorf("label not escaped:\n%s", out.String())
+	}
+}
+
+// TestMetrics_DuplicateName tests that registering a name twice panics.
+func TestMetrics_DuplicateName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("twice_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a duplicate metric")
+		}
+	}()
+	r.NewGauge("twice_total", "Second.")
+}
+
+// TestMetrics_WrongLabelCount tests that recording with the wrong number of label values panics.
+func TestMetrics_WrongLabelCount(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("labels_total", "Labeled.", "route")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for missing label values")
+		}
+	}()
+	c.Inc()
+}
+
+// TestMetrics_Concurrent tests recording and writing from many goroutines.
+func TestMetrics_Concurrent(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("concurrent_total", "Concurrent.", "worker")
+	h := r.NewHistogram("concurrent_seconds", "Concurrent.", DefaultBuckets)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Inc("w")
+				h.Observe(0.01)
+				if j%10 == 0 {
+					r.WriteText(&strings.Builder{})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var out strings.Builder
+	r.WriteText(&out)
+	if !strings.Contains(out.String(), `concurrent_total{worker="w"} 2000`) || !strings.Contains(out.String(), "concurrent_seconds_count 2000") {
+		t.Errorf("unexpected totals:\n%s", out.String())
+	}
+}
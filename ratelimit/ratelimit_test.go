@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLimiter returns a limiter with a controllable clock.
+func newTestLimiter(rate float64, burst int, now *time.Time) *Limiter {
+	limiter := NewLimiter(rate, burst)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+// TestRateLimit_Burst tests that a full bucket allows a burst and then refuses with a retry delay.
+func TestRateLimit_Burst(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(2, 3, &now)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("client"); !ok {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("client")
+	if ok {
+		t.Fatal("Expected request over the burst to be refused")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("Expected 500ms retry delay, got %v", wait)
+	}
+}
+
+// TestRateLimit_Refill tests that tokens come back at the configured rate.
+func TestRateLimit_Refill(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(1, 1, &now)
+
+	limiter.Allow("client")
+	if ok, _ := limiter.Allow("client"); ok {
+		t.Fatal("Expected empty bucket to refuse")
+	}
+	now = now.Add(time.Second)
+	if ok, _ := limiter.Allow("client"); !ok {
+		t.Error("Expected refilled bucket to allow")
+	}
+}
+
+// TestRateLimit_SeparateKeys tests that clients do not share a bucket.
+func TestRateLimit_SeparateKeys(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(1, 1, &now)
+
+	limiter.Allow("alice")
+	if ok, _ := limiter.Allow("bob"); !ok {
+		t.Error("Expected a separate bucket for bob")
+	}
+}
+
+// TestRateLimit_Sweep tests that idle buckets are dropped.
+func TestRateLimit_Sweep(t *testing.T) {
+	now := time.Date(2025, 11, 14, 10, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(1, 5, &now)
+
+	limiter.Allow("idle")
+	now = now.Add(2 * sweepInterval)
+	limiter.Allow("active")
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("Expected idle bucket to be swept")
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("Expected 1 bucket, got %d", len(limiter.buckets))
+	}
+}
@@ -0,0 +1,195 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"todo-app/storage"
+)
+
+// Output formats accepted by New.
+const (
+	Table string = "table"
+	JSON  string = "json"
+	CSV   string = "csv"
+	YAML  string = "yaml"
+	IDs   string = "ids"
+)
+
+// Formats lists the output formats, the default first.
+var Formats = []string{Table, JSON, CSV, YAML, IDs}
+
+// ErrUnknownFormat is returned by New for a format not in Formats.
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// Renderer writes items in one output format.
+// List writes a listing, which may be empty; Item writes a single item, as returned by get, add or edit.
+type Renderer interface {
+	List(w io.Writer, items storage.Items) error
+	Item(w io.Writer, item storage.Item) error
+}
+
+// New returns the renderer for a format in Formats.
+func New(format string) (Renderer, error) {
+	switch format {
+	case Table:
+		return tableRenderer{}, nil
+	case JSON:
+		return jsonRenderer{}, nil
+	case CSV:
+		return csvRenderer{}, nil
+	case YAML:
+		return yamlRenderer{}, nil
+	case IDs:
+		return idsRenderer{}, nil
+	}
+	return nil, fmt.Errorf("%w %q, use %s", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+}
+
+// sorted returns the items ordered by ID.
+func sorted(items storage.Items) []storage.Item {
+	list := make([]storage.Item, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	slices.SortFunc(list, func(a, b storage.Item) int { return a.ID - b.ID })
+	return list
+}
+
+// tableRenderer writes the tab separated listing for people to read.
+type tableRenderer struct{}
+
+func (tableRenderer) List(w io.Writer, items storage.Items) error {
+	return writeTable(w, sorted(items))
+}
+
+func (tableRenderer) Item(w io.Writer, item storage.Item) error {
+	return writeTable(w, []storage.Item{item})
+}
+
+// writeTable writes the header and one row per item.
+func writeTable(w io.Writer, items []storage.Item) error {
+	fmt.Fprintf(w, "%s\t%s\t\t%s\n", "ID", "Status", "Description")
+	fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Repeat("-", 1), strings.Repeat("-", 12), strings.Repeat("-", 120))
+	for _, item := range items {
+		if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t[%s]\n", item.ID, item.Status, item.Description, item.Created.Format(time.RFC822)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonRenderer writes items as the API does: a listing is an array, a single item an object.
+type jsonRenderer struct{}
+
+func (jsonRenderer) List(w io.Writer, items storage.Items) error {
+	return writeJSON(w, sorted(items))
+}
+
+func (jsonRenderer) Item(w io.Writer, item storage.Item) error {
+	return writeJSON(w, item)
+}
+
+// writeJSON writes v indented, followed by a newline.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// csvRenderer writes a header row and one record per item.
+type csvRenderer struct{}
+
+// csvHeader names the columns written by csvRenderer, matching the JSON field names.
+var csvHeader = []string{"id", "status", "description", "created", "owner"}
+
+func (csvRenderer) List(w io.Writer, items storage.Items) error {
+	return writeCSV(w, sorted(items))
+}
+
+func (csvRenderer) Item(w io.Writer, item storage.Item) error {
+	return writeCSV(w, []storage.Item{item})
+}
+
+// writeCSV writes the items with times in RFC 3339.
+func writeCSV(w io.Writer, items []storage.Item) error {
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, item := range items {
+		writer.Write([]string{strconv.Itoa(item.ID), item.Status, csvCell(item.Description), item.Created.Format(time.RFC3339), csvCell(item.Owner)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvCell prefixes text a spreadsheet would run as a formula with a quote, so it is shown as typed.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// yamlRenderer writes a listing as a sequence and a single item as a mapping, with the JSON field names.
+type yamlRenderer struct{}
+
+func (yamlRenderer) List(w io.Writer, items storage.Items) error {
+	list := sorted(items)
+	if len(list) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	for _, item := range list {
+		if err := writeYAML(w, item, "- ", "  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (yamlRenderer) Item(w io.Writer, item storage.Item) error {
+	return writeYAML(w, item, "", "")
+}
+
+// writeYAML writes one item as a mapping; the first line starts with first and the others with indent.
+// Strings are always double quoted, so descriptions cannot be read back as numbers, booleans or nested YAML.
+func writeYAML(w io.Writer, item storage.Item, first string, indent string) error {
+	lines := []string{
+		fmt.Sprintf("%sid: %d\n", first, item.ID),
+		fmt.Sprintf("%sdescription: %s\n", indent, strconv.Quote(item.Description)),
+		fmt.Sprintf("%sstatus: %s\n", indent, item.Status),
+		fmt.Sprintf("%screated: %s\n", indent, item.Created.Format(time.RFC3339)),
+	}
+	if item.Owner != "" {
+		lines = append(lines, fmt.Sprintf("%sowner: %s\n", indent, strconv.Quote(item.Owner)))
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// idsRenderer writes one item ID per line, for piping into other commands.
+type idsRenderer struct{}
+
+func (idsRenderer) List(w io.Writer, items storage.Items) error {
+	for _, item := range sorted(items) {
+		if _, err := fmt.Fprintln(w, item.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idsRenderer) Item(w io.Writer, item storage.Item) error {
+	_, err := fmt.Fprintln(w, item.ID)
+	return err
+}
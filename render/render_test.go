@@ -0,0 +1,175 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/storage"
+)
+
+var created = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// testItems has items out of ID order and a description that needs quoting in every format.
+var testItems = storage.Items{
+	2: {ID: 2, Description: "Second, \"quoted\"\nline", Status: "in_progress", Created: created, Owner: "alice"},
+	1: {ID: 1, Description: "First", Status: "not_started", Created: created},
+}
+
+// render writes the items with the renderer for format, as a listing or as the single item 2.
+func render(t *testing.T, format string, list bool) string {
+	t.Helper()
+	r, err := New(format)
+	if err != nil {
+		t.Fatalf("New(%q) failed: %v", format, err)
+	}
+	var buf bytes.Buffer
+	if list {
+		err = r.List(&buf, testItems)
+	} else {
+		err = r.Item(&buf, testItems[2])
+	}
+	if err != nil {
+		t.Fatalf("%s render failed: %v", format, err)
+	}
+	return buf.String()
+}
+
+// TestRender_New tests that every listed format has a renderer and others are rejected.
+func TestRender_New(t *testing.T) {
+	for _, format := range Formats {
+		if _, err := New(format); err != nil {
+			t.Errorf("New(%q) failed: %v", format, err)
+		}
+	}
+	if _, err := New("xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+// TestRender_Table tests the table rows are ordered by ID.
+func TestRender_Table(t *testing.T) {
+	out := render(t, Table, true)
+	if first, second := strings.Index(out, "1\tnot_started\tFirst\t[01 Oct 26 12:00 UTC]"), strings.Index(out, "2\tin_progress"); first < 0 || first > second {
+		t.Errorf("expected all items ordered by ID, got:\n%s", out)
+	}
+	if out := render(t, Table, false); strings.Contains(out, "First") || !strings.HasPrefix(out, "ID\tStatus") {
+		t.Errorf("expected only item 2 under the header, got:\n%s", out)
+	}
+}
+
+// TestRender_JSON tests that a listing is an array and a single item an object, both decoding to the items.
+func TestRender_JSON(t *testing.T) {
+	var list []storage.Item
+	if err := json.Unmarshal([]byte(render(t, JSON, true)), &list); err != nil || len(list) != 2 || list[0].ID != 1 || list[1] != testItems[2] {
+		t.Errorf("unexpected JSON listing: %+v, %v", list, err)
+	}
+	var item storage.Item
+	if err := json.Unmarshal([]byte(render(t, JSON, false)), &item); err != nil || item != testItems[2] {
+		t.Errorf("unexpected JSON item: %+v, %v", item, err)
+	}
+
+	r, _ := New(JSON)
+	var buf bytes.Buffer
+	r.List(&buf, storage.Items{})
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("expected an empty array, got %q", buf.String())
+	}
+}
+
+// TestRender_CSV tests the header and that quoted descriptions survive a round trip.
+func TestRender_CSV(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(render(t, CSV, true))).ReadAll()
+	if err != nil || len(records) != 3 {
+		t.Fatalf("unexpected CSV: %q, %v", records, err)
+	}
+	if strings.Join(records[0], ",") != "id,status,description,created,owner" {
+		t.Errorf("unexpected header %q", records[0])
+	}
+	if got := records[2]; got[0] != "2" || got[2] != testItems[2].Description || got[3] != "2026-10-01T12:00:00Z" || got[4] != "alice" {
+		t.Errorf("unexpected record %q", got)
+	}
+}
+
+// TestRender_CSVFormulas tests that cells a spreadsheet would run as formulas are prefixed with a quote.
+func TestRender_CSVFormulas(t *testing.T) {
+	for value, want := range map[string]string{
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-1":                "'-1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\t=1":              "'\t=1",
+		"a=1":               "a=1",
+		"":                  "",
+	} {
+		if got := csvCell(value); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", value, got, want)
+		}
+	}
+	item := storage.Item{ID: 3, Description: "=1+1", Status: "not_started", Created: testItems[1].Created, Owner: "@bob"}
+	var out bytes.Buffer
+	if err := (csvRenderer{}).Item(&out, item); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), ",'=1+1,") || !strings.Contains(out.String(), ",'@bob\n") {
+		t.Errorf("unexpected CSV %q", out.String())
+	}
+}
+
+// TestRender_YAML tests the sequence and mapping layouts and string quoting.
+func TestRender_YAML(t *testing.T) {
+	want := `- id: 1
+  description: "First"
+  status: not_started
+  created: 2026-10-01T12:00:00Z
+- id: 2
+  description: "Second, \"quoted\"\nline"
+  status: in_progress
+  created: 2026-10-01T12:00:00Z
+  owner: "alice"
+`
+	if out := render(t, YAML, true); out != want {
+		t.Errorf("unexpected YAML listing:\n%s", out)
+	}
+	if out := render(t, YAML, false); !strings.HasPrefix(out, "id: 2\ndescription: ") {
+		t.Errorf("unexpected YAML item:\n%s", out)
+	}
+}
+
+// failingWriter lets the given number of writes through, fails the next one and accepts the rest.
+type failingWriter struct {
+	writes int
+}
+
+var errWrite = errors.New("write failed")
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.writes--
+	if f.writes == -1 {
+		return 0, errWrite
+	}
+	return len(p), nil
+}
+
+// TestRender_YAMLWriteError tests that a failed write is returned even when the writes after it succeed.
+func TestRender_YAMLWriteError(t *testing.T) {
+	for writes := 0; writes < 5; writes++ {
+		w := &failingWriter{writes: writes}
+		if err := (yamlRenderer{}).Item(w, testItems[2]); !errors.Is(err, errWrite) {
+			t.Errorf("failing after %d writes: got %v, want %v", writes, err, errWrite)
+		}
+	}
+}
+
+// TestRender_IDs tests one ID per line in order.
+func TestRender_IDs(t *testing.T) {
+	if out := render(t, IDs, true); out != "1\n2\n" {
+		t.Errorf("unexpected IDs %q", out)
+	}
+	if out := render(t, IDs, false); out != "2\n" {
+		t.Errorf("unexpected ID %q", out)
+	}
+}
@@ -0,0 +1,143 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"todo-app/terminal"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lineEditor reads lines from a terminal in raw mode, with cursor movement, history and Tab completion.
+type lineEditor struct {
+	keys     *bufio.Reader
+	out      io.Writer
+	prompt   string
+	history  []string
+	complete func(line string) []string
+
+	line []rune
+	pos  int
+}
+
+// add appends a line to the history, unless it repeats the last one.
+func (e *lineEditor) add(line string) {
+	if len(e.history) == 0 || e.history[len(e.history)-1] != line {
+		e.history = append(e.history, line)
+	}
+}
+
+// readLine reads one line; Ctrl+C discards the line and Ctrl+D on an empty line returns io.EOF.
+func (e *lineEditor) readLine() (string, error) {
+	e.line, e.pos = e.line[:0], 0
+	// browsing is the history entry shown, len(history) for the line being typed, which draft keeps
+	browsing, draft := len(e.history), ""
+	e.redraw()
+	for {
+		key, err := terminal.ReadKey(e.keys)
+		if err != nil {
+			return "", err
+		}
+		switch key {
+		case "enter":
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.line), nil
+		case "ctrl-c":
+			fmt.Fprint(e.out, "^C\r\n")
+			e.line, e.pos = e.line[:0], 0
+			browsing = len(e.history)
+		case "ctrl-d":
+			if len(e.line) == 0 {
+				return "", io.EOF
+			}
+		case "backspace":
+			if e.pos > 0 {
+				e.line = slices.Delete(e.line, e.pos-1, e.pos)
+				e.pos--
+			}
+		case "delete":
+			if e.pos < len(e.line) {
+				e.line = slices.Delete(e.line, e.pos, e.pos+1)
+			}
+		case "ctrl-u":
+			e.line, e.pos = e.line[:0], 0
+		case "left":
+			e.pos = max(0, e.pos-1)
+		case "right":
+			e.pos = min(len(e.line), e.pos+1)
+		case "home":
+			e.pos = 0
+		case "end":
+			e.pos = len(e.line)
+		case "up", "down":
+			if browsing == len(e.history) {
+				draft = string(e.line)
+			}
+			if key == "up" {
+				browsing = max(0, browsing-1)
+			} else {
+				browsing = min(len(e.history), browsing+1)
+			}
+			shown := draft
+			if browsing < len(e.history) {
+				shown = e.history[browsing]
+			}
+			e.line = []rune(shown)
+			e.pos = len(e.line)
+		case "tab":
+			e.completeWord()
+		default:
+			if r, size := utf8.DecodeRuneInString(key); size == len(key) && unicode.IsPrint(r) {
+				e.line = slices.Insert(e.line, e.pos, r)
+				e.pos++
+			}
+		}
+		e.redraw()
+	}
+}
+
+// completeWord completes the word before the cursor: a single candidate is filled in,
+// several are filled in as far as they agree and listed when that adds nothing.
+func (e *lineEditor) completeWord() {
+	before := string(e.line[:e.pos])
+	candidates := e.complete(before)
+	if len(candidates) == 0 {
+		return
+	}
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+	completion := candidates[0] + " "
+	if len(candidates) > 1 {
+		completion = commonPrefix(candidates)
+		if completion == word {
+			fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+			return
+		}
+	}
+	insert := []rune(completion[len(word):])
+	e.line = slices.Insert(e.line, e.pos, insert...)
+	e.pos += len(insert)
+}
+
+// redraw rewrites the prompt and line and puts the cursor back.
+func (e *lineEditor) redraw() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.line))
+	if back := len(e.line) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// commonPrefix returns the longest prefix all the words share.
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
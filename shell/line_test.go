@@ -0,0 +1,63 @@
+package shell
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readLines types keys into a line editor and returns the lines it read.
+func readLines(t *testing.T, keys string, history []string, complete func(string) []string) ([]string, string) {
+	t.Helper()
+	var out strings.Builder
+	e := &lineEditor{keys: bufio.NewReader(strings.NewReader(keys)), out: &out, prompt: "> ", history: history, complete: complete}
+	var lines []string
+	for {
+		line, err := e.readLine()
+		if errors.Is(err, io.EOF) {
+			return lines, out.String()
+		}
+		if err != nil {
+			t.Fatalf("readLine failed: %v", err)
+		}
+		lines = append(lines, line)
+		e.add(line)
+	}
+}
+
+func noCompletion(string) []string { return nil }
+
+// TestShell_LineEditing tests cursor movement, deletion and Ctrl+C.
+func TestShell_LineEditing(t *testing.T) {
+	lines, out := readLines(t, "ad milk\x1b[D\x1b[D\x1b[D\x1b[D\x1b[Dd\x1b[Fx\x7f\r"+"typo\x03"+"xls\x1b[H\x1b[3~\r", nil, noCompletion)
+	if len(lines) != 2 || lines[0] != "add milk" || lines[1] != "ls" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+	if !strings.Contains(out, "typo\x1b[K^C\r\n") {
+		t.Errorf("expected Ctrl+C to be echoed:\n%q", out)
+	}
+}
+
+// TestShell_LineHistory tests browsing the history with up and down, keeping the line being typed.
+func TestShell_LineHistory(t *testing.T) {
+	lines, _ := readLines(t, "\x1b[A\x1b[A\r"+"new\x1b[A\x1b[B\r", []string{"first", "second"}, noCompletion)
+	if len(lines) != 2 || lines[0] != "first" || lines[1] != "new" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+}
+
+// TestShell_LineCompletion tests that Tab fills in one candidate, a common prefix, or lists the candidates.
+func TestShell_LineCompletion(t *testing.T) {
+	complete := func(line string) []string {
+		return New(t.Context(), newFake(), io.Discard).Complete(line)
+	}
+	lines, out := readLines(t, "ed\t1\t\r"+"e\t\t\r", nil, complete)
+	if len(lines) != 2 || lines[0] != "edit 1" || lines[1] != "e" {
+		t.Errorf("unexpected lines %q", lines)
+	}
+	if !strings.Contains(out, "\r\nedit  exit\r\n") {
+		t.Errorf("expected the candidates to be listed:\n%q", out)
+	}
+}
@@ -0,0 +1,384 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"todo-app/render"
+	"todo-app/storage"
+	"todo-app/terminal"
+)
+
+// prompt is shown before every command on a terminal.
+const prompt = "todo> "
+
+// maxHistory is the number of lines kept from the history file.
+const maxHistory = 1000
+
+// Service is the part of the actor's method set the shell uses, so it runs against the data file or a server.
+type Service interface {
+	Create(ctx context.Context, description string, status string) (storage.Item, error)
+	Update(ctx context.Context, id int, description string, status string) (storage.Item, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, item storage.Item) (storage.Item, error)
+	ListAll(ctx context.Context) (storage.Items, error)
+	List(ctx context.Context, id int) (storage.Item, error)
+}
+
+// Shell runs commands against the items for one session.
+type Shell struct {
+	ctx   context.Context
+	items Service
+	out   io.Writer
+	undo  []change
+	exit  bool
+}
+
+// change is a command that can be undone.
+type change struct {
+	// revert undoes the command and returns what it did
+	revert func() (string, error)
+}
+
+// shellCommand is a command of the shell.
+type shellCommand struct {
+	name    string
+	args    string
+	summary string
+	run     func(s *Shell, args []string) error
+	// complete returns the candidates for argument n, counted from 0
+	complete func(s *Shell, n int, args []string) []string
+}
+
+// commands returns the shell commands in the order help lists them.
+func commands() []shellCommand {
+	return []shellCommand{
+		{name: "add", args: "<description>", summary: "Create an item", run: (*Shell).add},
+		{name: "ls", args: "[status]", summary: "List the items, optionally only those with a status", run: (*Shell).ls, complete: completeStatus},
+		{name: "done", args: "<id>", summary: "Mark an item as finished", run: (*Shell).done, complete: completeID},
+		{name: "edit", args: "<id> <description>", summary: "Change the description of an item; Tab after the ID fills in the current one", run: (*Shell).edit, complete: completeEdit},
+		{name: "rm", args: "<id>", summary: "Delete an item", run: (*Shell).rm, complete: completeID},
+		{name: "undo", summary: "Undo the last add, done, edit or rm", run: (*Shell).undoLast},
+		{name: "help", summary: "Show this help", run: (*Shell).help, complete: completeCommand},
+		{name: "exit", summary: "Leave the shell (also Ctrl+D)", run: func(s *Shell, args []string) error { s.exit = true; return nil }},
+	}
+}
+
+// findCommand looks up a command by name.
+func findCommand(name string) (shellCommand, bool) {
+	i := slices.IndexFunc(commands(), func(cmd shellCommand) bool { return cmd.name == name })
+	if i < 0 {
+		return shellCommand{}, false
+	}
+	return commands()[i], true
+}
+
+// New returns a shell writing command output to out.
+func New(ctx context.Context, items Service, out io.Writer) *Shell {
+	return &Shell{ctx: ctx, items: items, out: out}
+}
+
+// Run reads commands from in until exit or the end of the input, writing errors to out and carrying on.
+// On a terminal lines can be edited, with history kept in historyFile and Tab completion of commands and item IDs;
+// otherwise lines are read as they come, so scripts can be piped in.
+func Run(ctx context.Context, items Service, in *os.File, out *os.File, historyFile string) error {
+	s := New(ctx, items, out)
+	restore, err := terminal.MakeRaw(in, out)
+	if err != nil {
+		return s.runLines(in)
+	}
+	defer restore()
+
+	history, err := LoadHistory(historyFile)
+	if err != nil {
+		fmt.Fprintf(out, "History not loaded: %v\r\n", err)
+	}
+	editor := &lineEditor{keys: bufio.NewReader(in), out: out, prompt: prompt, history: history, complete: s.Complete}
+	fmt.Fprint(out, "Type help for the commands, Tab to complete.\r\n")
+	for !s.exit {
+		line, err := editor.readLine()
+		if errors.Is(err, io.EOF) {
+			fmt.Fprint(out, "\r\n")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		editor.add(line)
+		if err := AppendHistory(historyFile, line); err != nil {
+			fmt.Fprintf(out, "History not saved: %v\r\n", err)
+		}
+		s.report(s.Execute(line))
+	}
+	return nil
+}
+
+// runLines runs each line of in as a command.
+func (s *Shell) runLines(in io.Reader) error {
+	lines := bufio.NewScanner(in)
+	for !s.exit && lines.Scan() {
+		s.report(s.Execute(lines.Text()))
+	}
+	return lines.Err()
+}
+
+// report writes the error of a command.
+func (s *Shell) report(err error) {
+	if err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+	}
+}
+
+// Execute runs one command line; empty lines do nothing.
+func (s *Shell) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	cmd, ok := findCommand(fields[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q, type help for the commands", fields[0])
+	}
+	return cmd.run(s, fields[1:])
+}
+
+func (s *Shell) add(args []string) error {
+	if len(args) == 0 {
+		return errors.New("add needs a description")
+	}
+	item, err := s.items.Create(s.ctx, strings.Join(args, " "), "")
+	if err != nil {
+		return fmt.Errorf("create item: %w", err)
+	}
+	s.push(func() (string, error) {
+		return fmt.Sprintf("Removed item %d", item.ID), s.items.Delete(s.ctx, item.ID)
+	})
+	fmt.Fprintf(s.out, "Added item %d\n", item.ID)
+	return nil
+}
+
+func (s *Shell) ls(args []string) error {
+	if len(args) > 1 || len(args) == 1 && !storage.ValidStatus(args[0]) {
+		return fmt.Errorf("ls takes an optional status: %s", strings.Join(storage.Statuses, ", "))
+	}
+	items, err := s.items.ListAll(s.ctx)
+	if err != nil && !errors.Is(err, storage.ErrNoItems) {
+		return fmt.Errorf("list items: %w", err)
+	}
+	status := ""
+	if len(args) == 1 {
+		status = args[0]
+	}
+	r, _ := render.New(render.Table)
+	return r.List(s.out, items.WithStatus(status))
+}
+
+func (s *Shell) done(args []string) error {
+	id, err := itemID("done", args)
+	if err != nil {
+		return err
+	}
+	return s.update(id, "", "is_finished")
+}
+
+func (s *Shell) edit(args []string) error {
+	if len(args) < 2 {
+		return errors.New("edit needs an item ID and the new description")
+	}
+	id, err := itemID("edit", args[:1])
+	if err != nil {
+		return err
+	}
+	return s.update(id, strings.Join(args[1:], " "), "")
+}
+
+func (s *Shell) rm(args []string) error {
+	id, err := itemID("rm", args)
+	if err != nil {
+		return err
+	}
+	current, err := s.items.List(s.ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete item %d: %w", id, err)
+	}
+	if err := s.items.Delete(s.ctx, id); err != nil {
+		return fmt.Errorf("delete item %d: %w", id, err)
+	}
+	// the item comes back under its ID and in its list, so earlier undo entries still apply to it
+	s.push(func() (string, error) {
+		_, err := s.items.Restore(s.ctx, current)
+		return fmt.Sprintf("Restored item %d", id), err
+	})
+	fmt.Fprintf(s.out, "Deleted item %d\n", id)
+	return nil
+}
+
+func (s *Shell) undoLast(args []string) error {
+	if len(s.undo) == 0 {
+		return errors.New("nothing to undo")
+	}
+	last := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	done, err := last.revert()
+	if err != nil {
+		return fmt.Errorf("undo: %w", err)
+	}
+	fmt.Fprintln(s.out, done)
+	return nil
+}
+
+func (s *Shell) help(args []string) error {
+	for _, cmd := range commands() {
+		if len(args) > 0 && cmd.name != args[0] {
+			continue
+		}
+		fmt.Fprintf(s.out, "  %-26s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	return nil
+}
+
+// update changes an item, keeping its current description or status where the new one is empty, and records how to undo it.
+func (s *Shell) update(id int, description string, status string) error {
+	current, err := s.items.List(s.ctx, id)
+	if err != nil {
+		return fmt.Errorf("update item %d: %w", id, err)
+	}
+	changed := current.Changed(description, status)
+	item, err := s.items.Update(s.ctx, id, changed.Description, changed.Status)
+	if err != nil {
+		return fmt.Errorf("update item %d: %w", id, err)
+	}
+	s.push(func() (string, error) {
+		_, err := s.items.Update(s.ctx, id, current.Description, current.Status)
+		return fmt.Sprintf("Changed item %d back", id), err
+	})
+	fmt.Fprintf(s.out, "Item %d: %s [%s]\n", item.ID, item.Description, item.Status)
+	return nil
+}
+
+// push records how to undo the command that just ran.
+func (s *Shell) push(revert func() (string, error)) {
+	s.undo = append(s.undo, change{revert: revert})
+}
+
+// Complete returns the candidates for the last word of line: command names, item IDs, statuses or,
+// after edit and an ID, the item's current description.
+func (s *Shell) Complete(line string) []string {
+	fields := strings.Fields(line)
+	word := ""
+	if len(fields) > 0 && !strings.HasSuffix(line, " ") {
+		word = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+	var candidates []string
+	if len(fields) == 0 {
+		candidates = completeCommand(s, 0, nil)
+	} else if cmd, ok := findCommand(fields[0]); ok && cmd.complete != nil {
+		candidates = cmd.complete(s, len(fields)-1, fields[1:])
+	}
+	return slices.DeleteFunc(candidates, func(c string) bool { return !strings.HasPrefix(c, word) })
+}
+
+// completeCommand completes the first argument with a command name.
+func completeCommand(s *Shell, n int, args []string) []string {
+	if n > 0 {
+		return nil
+	}
+	var names []string
+	for _, cmd := range commands() {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+// completeStatus completes the first argument with a status.
+func completeStatus(s *Shell, n int, args []string) []string {
+	if n > 0 {
+		return nil
+	}
+	return storage.Statuses
+}
+
+// completeID completes the first argument with the IDs of the items.
+func completeID(s *Shell, n int, args []string) []string {
+	if n > 0 {
+		return nil
+	}
+	items, _ := s.items.ListAll(s.ctx)
+	ids := make([]int, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	var candidates []string
+	for _, id := range ids {
+		candidates = append(candidates, strconv.Itoa(id))
+	}
+	return candidates
+}
+
+// completeEdit completes the ID and then the current description of that item.
+func completeEdit(s *Shell, n int, args []string) []string {
+	if n == 0 {
+		return completeID(s, n, args)
+	}
+	if n > 1 {
+		return nil
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil
+	}
+	item, err := s.items.List(s.ctx, id)
+	if err != nil {
+		return nil
+	}
+	return []string{item.Description}
+}
+
+// itemID parses the single item ID argument of a command.
+func itemID(name string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s needs one item ID", name)
+	}
+	return storage.ParseID(args[0])
+}
+
+// LoadHistory reads the last lines of the history file; a missing file is an empty history.
+func LoadHistory(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	return lines[max(0, len(lines)-maxHistory):], nil
+}
+
+// AppendHistory adds a line to the history file, creating it readable only by the user.
+func AppendHistory(file string, line string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
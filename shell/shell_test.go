@@ -0,0 +1,142 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"todo-app/internal/itemstest"
+	"todo-app/storage"
+)
+
+// newFake returns a fake with two items far apart, so IDs and completions are told apart.
+func newFake() *itemstest.Fake {
+	return itemstest.New(
+		storage.Item{ID: 1, Description: "Buy milk", Status: "not_started"},
+		storage.Item{ID: 12, Description: "Fix the bike", Status: "in_progress"},
+	)
+}
+
+// TestShell_Commands tests a session of commands read from a script.
+func TestShell_Commands(t *testing.T) {
+	fake := newFake()
+	var out strings.Builder
+	s := New(context.Background(), fake, &out)
+	script := "add Walk  the dog\n\ndone 1\nedit 12 Fix the car\nls in_progress\nbogus\ndone x\nexit\nadd never run\n"
+	if err := s.runLines(strings.NewReader(script)); err != nil {
+		t.Fatalf("runLines failed: %v", err)
+	}
+	for _, want := range []string{
+		"Added item 13\n",
+		"Item 1: Buy milk [is_finished]\n",
+		"Item 12: Fix the car [in_progress]\n",
+		"12\tin_progress\tFix the car",
+		`Error: unknown command "bogus"`,
+		`Error: invalid item ID "x"`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in the output:\n%s", want, out.String())
+		}
+	}
+	if fake.Items[13].Description != "Walk the dog" || len(fake.Items) != 3 {
+		t.Errorf("unexpected items after the script: %+v", fake.Items)
+	}
+}
+
+// TestShell_Undo tests that undo reverts changes in reverse order.
+func TestShell_Undo(t *testing.T) {
+	fake := newFake()
+	var out strings.Builder
+	s := New(context.Background(), fake, &out)
+	for _, line := range []string{"add Third", "edit 1 Buy oat milk", "done 1", "rm 12"} {
+		if err := s.Execute(line); err != nil {
+			t.Fatalf("%s failed: %v", line, err)
+		}
+	}
+
+	s.Execute("undo")
+	if restored := fake.Items[12]; restored.Description != "Fix the bike" || restored.Status != "in_progress" {
+		t.Errorf("expected rm undone under the same ID, got %+v", fake.Items)
+	}
+	s.Execute("undo")
+	s.Execute("undo")
+	if item := fake.Items[1]; item.Description != "Buy milk" || item.Status != "not_started" {
+		t.Errorf("expected edit and done undone, got %+v", item)
+	}
+	s.Execute("undo")
+	if _, ok := fake.Items[13]; ok {
+		t.Error("expected add undone")
+	}
+	if err := s.Execute("undo"); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("expected nothing left to undo, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Restored item 12\n") {
+		t.Errorf("expected the restore to be reported:\n%s", out.String())
+	}
+}
+
+// TestShell_UndoEditAfterRm tests that undoing rm keeps the ID, owner and creation time,
+// so undoing an earlier edit of the same item still finds it.
+func TestShell_UndoEditAfterRm(t *testing.T) {
+	fake := newFake()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	fake.Items[3] = storage.Item{ID: 3, Description: "Shared item", Status: "not_started", Created: created, Owner: "alice"}
+	s := New(context.Background(), fake, io.Discard)
+	for _, line := range []string{"edit 3 X", "rm 3", "undo", "undo"} {
+		if err := s.Execute(line); err != nil {
+			t.Fatalf("%s failed: %v", line, err)
+		}
+	}
+	want := storage.Item{ID: 3, Description: "Shared item", Status: "not_started", Created: created, Owner: "alice"}
+	if got := fake.Items[3]; got != want {
+		t.Errorf("expected %+v back, got %+v", want, got)
+	}
+}
+
+// TestShell_Complete tests completion of commands, item IDs, statuses and the description being edited.
+func TestShell_Complete(t *testing.T) {
+	s := New(context.Background(), newFake(), &strings.Builder{})
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{"add", "ls", "done", "edit", "rm", "undo", "help", "exit"}},
+		{"e", []string{"edit", "exit"}},
+		{"done ", []string{"1", "12"}},
+		{"rm 1", []string{"1", "12"}},
+		{"done 1 ", nil},
+		{"ls in", []string{"in_progress"}},
+		{"edit 12 ", []string{"Fix the bike"}},
+		{"edit 99 ", nil},
+		{"add ", nil},
+		{"help u", []string{"undo"}},
+	}
+	for _, tt := range tests {
+		if got := s.Complete(tt.line); !slices.Equal(got, tt.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestShell_History tests that history lines are appended and read back.
+func TestShell_History(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+	if lines, err := LoadHistory(file); err != nil || lines != nil {
+		t.Fatalf("expected an empty history for a missing file, got %q, %v", lines, err)
+	}
+	for _, line := range []string{"add Buy milk", "ls"} {
+		if err := AppendHistory(file, line); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
+	}
+	if lines, err := LoadHistory(file); err != nil || !slices.Equal(lines, []string{"add Buy milk", "ls"}) {
+		t.Errorf("unexpected history %q, %v", lines, err)
+	}
+	if _, err := LoadHistory(t.TempDir()); err == nil || errors.Is(err, storage.ErrNoItems) {
+		t.Errorf("expected an error reading a directory, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "net"
+
+// listenSocket creates the Unix domain socket; there is no umask on this system, see listen.
+func listenSocket(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenSocket creates the Unix domain socket with a umask that leaves it to the current user,
+// so other local users cannot connect before its mode is set.
+func listenSocket(path string) (net.Listener, error) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
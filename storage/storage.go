@@ -9,13 +9,45 @@ import (
 	"log/slog"
 	"os"
 	"slices"
-	"strings"
+	"strconv"
 	"time"
+	"todo-app/metrics"
+	"unicode/utf8"
 )
 
 var (
 	itemsList     Items = Items{}
 	itemsDatafile string
+
+	// lastSave and lastSaveErr record the outcome of the most recent Save
+	lastSave    time.Time
+	lastSaveErr error
+)
+
+// DefaultMaxDescriptionLength is the longest description accepted unless changed with SetMaxDescriptionLength.
+const DefaultMaxDescriptionLength int = 1000
+
+// maxDescriptionLength is the longest description accepted, in characters.
+var maxDescriptionLength = DefaultMaxDescriptionLength
+
+var (
+	ErrInvalidID        = errors.New("invalid item ID")
+	ErrEmptyDescription = errors.New("description cannot be empty")
+	ErrLongDescription  = errors.New("description is too long")
+	ErrInvalidStatus    = errors.New("invalid status value")
+	ErrItemNotFound     = errors.New("item not found")
+	ErrItemExists       = errors.New("item ID is already in use")
+	ErrNoItems          = errors.New("no items available")
+
+	ErrDataFileNotOpen     = errors.New("data file is not open")
+	ErrDataFileNotReadable = errors.New("data file is not readable")
+	ErrDataFileNotWritable = errors.New("data file is not writable")
+)
+
+var (
+	saveDuration = metrics.NewHistogram("todo_storage_save_duration_seconds", "Time taken to write the data file.", metrics.DefaultBuckets)
+	fileSize     = metrics.NewGauge("todo_storage_file_size_bytes", "Size of the data file after the last load or save.")
+	itemCount    = metrics.NewGauge("todo_items", "Items in the data file by status.", "status")
 )
 
 type Item struct {
@@ -23,10 +55,53 @@ type Item struct {
 	Description string    `json:"description"`
 	Status      string    `json:"status"`
 	Created     time.Time `json:"created"`
+	Owner       string    `json:"owner,omitempty"`
 }
 
 type Items map[int]Item
 
+// Statuses are the valid item statuses, in the order work on an item goes through them.
+var Statuses = []string{"not_started", "in_progress", "is_finished"}
+
+// ValidStatus reports whether status is one of Statuses.
+func ValidStatus(status string) bool {
+	return slices.Contains(Statuses, status)
+}
+
+// ParseID parses an item ID typed by a user; IDs start at 1.
+func ParseID(text string) (int, error) {
+	id, err := strconv.Atoi(text)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w %q", ErrInvalidID, text)
+	}
+	return id, nil
+}
+
+// WithStatus returns the items with the status, or all of them when status is empty.
+func (items Items) WithStatus(status string) Items {
+	if status == "" {
+		return items
+	}
+	filtered := Items{}
+	for id, item := range items {
+		if item.Status == status {
+			filtered[id] = item
+		}
+	}
+	return filtered
+}
+
+// Changed returns the item with a new description and status, keeping the current ones where they are empty.
+func (item Item) Changed(description string, status string) Item {
+	if description != "" {
+		item.Description = description
+	}
+	if status != "" {
+		item.Status = status
+	}
+	return item
+}
+
 // newItem creates a new Item with the given parameters.
 func newItem(id int, description string, status string) Item {
 	item := Item{
@@ -45,25 +120,32 @@ func GetDataFile() string {
 
 // Save writes the current items list to the specified json file.
 func Save(ctx context.Context, datafile string) error {
+	err := save(ctx, datafile)
+	lastSave, lastSaveErr = time.Now().UTC(), err
+	return err
+}
+
+// save writes the items list, recording metrics when it succeeds.
+func save(ctx context.Context, datafile string) error {
+	started := time.Now()
 	if data, err := json.Marshal(itemsList); err != nil {
-		fmt.Printf("Save failed converting todo list to json, error: %s \n", err)
 		slog.ErrorContext(ctx, "Save failed converting todo list to json", "error", err)
 		return err
 	} else {
 		if destination, err := openFileWriteTruncate(datafile); err != nil {
-			fmt.Printf("Save failed getting file, error: %s, datafile: %s \n", err, datafile)
 			slog.ErrorContext(ctx, "Save failed getting file", "error", err, "datafile", datafile)
 			return err
 		} else {
 			defer destination.Close()
 			if _, err := destination.Write(data); err != nil {
-				fmt.Printf("Save to file failed, error: %s, datafile: %s \n", err, datafile)
 				slog.ErrorContext(ctx, "Save to file failed", "error", err, "datafile", datafile)
 				return err
 			}
+			saveDuration.Observe(time.Since(started).Seconds())
+			fileSize.Set(float64(len(data)))
 		}
 	}
-	fmt.Printf("Saved data to file, datafile: %s \n", datafile)
+	recordItemCounts()
 	slog.InfoContext(ctx, "Saved data to file", "datafile", datafile)
 	return nil
 }
@@ -72,7 +154,6 @@ func Save(ctx context.Context, datafile string) error {
 func Load(ctx context.Context, datafile string) (Items, error) {
 	destination, err := openFileReadWrite(datafile)
 	if err != nil {
-		fmt.Printf("Load failed listing file, error: %s, datafile: %s\n", err, datafile)
 		slog.ErrorContext(ctx, "Load failed listing file", "error", err, "datafile", datafile)
 		return Items{}, err
 	}
@@ -87,7 +168,6 @@ func Open(ctx context.Context, datafile string) error {
 	// load existing
 	items, err := Load(ctx, datafile)
 	if err != nil {
-		fmt.Printf("Open file failed, error: %s, datafile: %s\n", err, datafile)
 		slog.ErrorContext(ctx, "Open file failed", "error", err, "datafile", datafile)
 		return err
 	}
@@ -95,64 +175,108 @@ func Open(ctx context.Context, datafile string) error {
 	// set global items list
 	itemsList = items
 	itemsDatafile = datafile
+	recordItemCounts()
+	if info, err := os.Stat(datafile); err == nil {
+		fileSize.Set(float64(info.Size()))
+	}
 
 	// log loaded items count
-	fmt.Printf("Opened file and loaded items, count: %d, datafile: %s \n", len(itemsList), datafile)
 	slog.InfoContext(ctx, "Opened file and loaded items", "count", len(itemsList), "datafile", datafile)
 	return nil
 }
 
 // CreateItem creates a new item with the given description and adds it to the items list.
 func CreateItem(ctx context.Context, description string, status string) (Item, error) {
+	return CreateItemFor(ctx, "", description, status)
+}
+
+// CreateItemFor creates a new item owned by the given user; an empty owner creates an unowned item.
+func CreateItemFor(ctx context.Context, owner string, description string, status string) (Item, error) {
 	// Validate inputs
-	if description == "" {
-		return Item{}, errors.New("description cannot be empty")
+	if err := validateDescription(description); err != nil {
+		return Item{}, err
 	}
 	if status != "" {
-		if status != "not_started" && status != "in_progress" && status != "is_finished" {
-			return Item{}, errors.New("invalid status value")
+		if !ValidStatus(status) {
+			return Item{}, ErrInvalidStatus
 		}
 	} else {
-		status = "not_started"
+		status = Statuses[0]
 	}
 
 	// Determine next key
 	itemKeys := collectKeys(itemsList)
 	nextKey := highestKey(itemKeys) + 1
 	item := newItem(nextKey, description, status)
+	item.Owner = owner
 	itemsList[nextKey] = item
 
 	// Commit to file
 	commitFile(ctx)
 
 	// Log creation
-	slog.InfoContext(ctx, "Created new item", "ID", item.ID, "Description", item.Description, "Status:", item.Status)
-	fmt.Printf("Created new item, ID: %d, Description: %s, Status: %s \n", item.ID, item.Description, item.Status)
+	slog.InfoContext(ctx, "Created new item", "ID", item.ID, "Description", item.Description, "Status:", item.Status, "Owner", item.Owner)
 
 	// return new item ID
 	return item, nil
 }
 
+// RestoreItem puts a deleted item back under its ID, keeping its owner and creation time.
+// The ID must not have been taken by a new item in the meantime.
+func RestoreItem(ctx context.Context, item Item) (Item, error) {
+	// Validate inputs
+	if item.ID <= 0 {
+		return Item{}, ErrInvalidID
+	}
+	if err := validateDescription(item.Description); err != nil {
+		return Item{}, err
+	}
+	if !ValidStatus(item.Status) {
+		return Item{}, ErrInvalidStatus
+	}
+	if _, exists := itemsList[item.ID]; exists {
+		return Item{}, ErrItemExists
+	}
+	if item.Created.IsZero() {
+		item.Created = time.Now().UTC()
+	}
+
+	// restore item
+	itemsList[item.ID] = item
+
+	// Commit to file
+	commitFile(ctx)
+
+	// Log restore
+	slog.InfoContext(ctx, "Restored item", "ID", item.ID, "Description", item.Description, "Status", item.Status, "Owner", item.Owner)
+
+	// return restored item
+	return item, nil
+}
+
 // UpdateItem updates an existing item in the items list.
 func UpdateItem(ctx context.Context, item Item) (Item, error) {
 	// Validate inputs
 	if item.ID <= 0 {
-		return Item{}, errors.New("invalid item ID")
+		return Item{}, ErrInvalidID
 	}
-	if item.Description == "" {
-		return Item{}, errors.New("description cannot be empty")
+	if err := validateDescription(item.Description); err != nil {
+		return Item{}, err
 	}
-	if item.Status != "not_started" && item.Status != "in_progress" && item.Status != "is_finished" {
-		return Item{}, errors.New("invalid status value")
+	if !ValidStatus(item.Status) {
+		return Item{}, ErrInvalidStatus
 	}
 
-	// Update the item
-	fmt.Printf("Updating item %d:\n", item.ID)
-
 	// check item exists
 	current, exists := itemsList[item.ID]
 	if !exists {
-		return Item{}, errors.New("item not found")
+		return Item{}, ErrItemNotFound
+	}
+
+	// the creation time and owner are fixed when the item is created
+	item.Created = current.Created
+	if item.Owner == "" {
+		item.Owner = current.Owner
 	}
 
 	// update item
@@ -163,7 +287,6 @@ func UpdateItem(ctx context.Context, item Item) (Item, error) {
 
 	// Log update
 	slog.InfoContext(ctx, "Updated item", "ID", item.ID, "Old Description", current.Description, "New Description", item.Description, "Old Status", current.Status, "New Status", item.Status)
-	fmt.Printf("Updated item, ID: %d, Old Description: %s, New Description: %s, Old Status: %s, New Status: %s \n", item.ID, current.Description, item.Description, current.Status, item.Status)
 
 	// return updated item
 	return item, nil
@@ -173,16 +296,13 @@ func UpdateItem(ctx context.Context, item Item) (Item, error) {
 func DeleteItem(ctx context.Context, index int) error {
 	// validate inputs
 	if index <= 0 {
-		return errors.New("invalid item ID")
+		return ErrInvalidID
 	}
 
-	// Delete the item
-	fmt.Printf("Deleting item %d:\n", index)
-
 	// check item exists
 	_, exists := itemsList[index]
 	if !exists {
-		return errors.New("item not found")
+		return ErrItemNotFound
 	}
 
 	// delete item
@@ -193,44 +313,15 @@ func DeleteItem(ctx context.Context, index int) error {
 
 	// Log deletion
 	slog.InfoContext(ctx, "Deleted item", "ID", index)
-	fmt.Printf("Deleted item, ID: %d \n", index)
 
 	// return nil error
 	return nil
 }
 
-// ListItem lists items; if index is 0, lists all items, otherwise lists the item with the given ID.
-func ListItem(index int) error {
-	// List items
-	fmt.Printf("Listing items:\n")
-
-	// print header
-	fmt.Printf("%s\t%s\t\t%s\n", "ID", "Status", "Description")
-	fmt.Printf("%s\t%s\t%s\n", strings.Repeat("-", 1), strings.Repeat("-", 12), strings.Repeat("-", 120))
-
-	// reference current items list
-	if len(itemsList) > 0 {
-		if listItem, ok := itemsList[index]; ok {
-			fmt.Printf("%d\t%s\t%s\t[%s]\n", listItem.ID, listItem.Status, listItem.Description, listItem.Created.Format(time.RFC822))
-		} else {
-			itemKeys := collectKeys(itemsList)
-			slices.Sort(itemKeys)
-			for _, i := range itemKeys {
-				listItem := itemsList[i]
-				fmt.Printf("%d\t%s\t%s\t[%s]\n", listItem.ID, listItem.Status, listItem.Description, listItem.Created.Format(time.RFC822))
-			}
-		}
-	} else {
-		// no items to list
-		return errors.New("no items to list")
-	}
-	return nil
-}
-
 func GetItemByID(id int) (Item, error) {
 	// validate inputs
 	if id <= 0 {
-		return Item{}, errors.New("invalid item ID")
+		return Item{}, ErrInvalidID
 	}
 	// retrieve item by ID
 	if len(itemsList) > 0 {
@@ -238,10 +329,10 @@ func GetItemByID(id int) (Item, error) {
 		if ok {
 			return item, nil
 		} else {
-			return Item{}, errors.New("item not found")
+			return Item{}, ErrItemNotFound
 		}
 	} else {
-		return Item{}, errors.New("no items available")
+		return Item{}, ErrNoItems
 	}
 }
 
@@ -250,7 +341,70 @@ func GetAllItems() (Items, error) {
 	if len(itemsList) > 0 {
 		return itemsList, nil
 	}
-	return Items{}, errors.New("no items available")
+	return Items{}, ErrNoItems
+}
+
+// SetMaxDescriptionLength changes the longest description accepted, in characters.
+func SetMaxDescriptionLength(length int) {
+	maxDescriptionLength = length
+}
+
+// validateDescription checks a description is not empty and within the length limit.
+func validateDescription(description string) error {
+	if description == "" {
+		return ErrEmptyDescription
+	}
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return fmt.Errorf("%w: at most %d characters", ErrLongDescription, maxDescriptionLength)
+	}
+	return nil
+}
+
+// Health is the state of the data file reported by Check.
+type Health struct {
+	// File is nil when the data file can be opened for reading and writing.
+	File error
+	// LastSave is when the data file was last written, zero if it has not been written by this process,
+	// and LastSaveErr the error of that write.
+	LastSave    time.Time
+	LastSaveErr error
+}
+
+// Check reports whether the data file can be read and written and how the last save went.
+// It must be called from the goroutine that owns storage, like any other storage function.
+func Check() Health {
+	return Health{File: checkDataFile(itemsDatafile), LastSave: lastSave, LastSaveErr: lastSaveErr}
+}
+
+// checkDataFile opens the data file for reading and for writing without changing it.
+func checkDataFile(datafile string) error {
+	if datafile == "" {
+		return ErrDataFileNotOpen
+	}
+	reader, err := os.Open(datafile)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataFileNotReadable, err)
+	}
+	reader.Close()
+	writer, err := os.OpenFile(datafile, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataFileNotWritable, err)
+	}
+	return writer.Close()
+}
+
+// recordItemCounts updates the item count metrics from the current items list.
+func recordItemCounts() {
+	counts := map[string]int{}
+	for _, status := range Statuses {
+		counts[status] = 0
+	}
+	for _, item := range itemsList {
+		counts[item.Status]++
+	}
+	for status, count := range counts {
+		itemCount.Set(float64(count), status)
+	}
 }
 
 // commitFile saves the current items list to the data file if it is open.
@@ -291,13 +445,11 @@ func openFileWriteTruncate(fileName string) (*os.File, error) {
 func loadItem(ctx context.Context, destination io.Reader) (Items, error) {
 	// read all data from the reader
 	if item, err := io.ReadAll(destination); err != nil {
-		fmt.Println(err)
-		fmt.Printf("Load item failed, error: %s \n", err)
 		slog.ErrorContext(ctx, "Load item failed", "error", err)
 		return Items{}, err
 	} else if len(item) == 0 {
 		// not neccessarily an error
-		fmt.Printf("No data to load, returning empty item list \n")
+		slog.InfoContext(ctx, "No data to load, returning empty item list")
 		return Items{}, nil
 	} else {
 		// unmarshal json data
@@ -305,7 +457,6 @@ func loadItem(ctx context.Context, destination io.Reader) (Items, error) {
 		itemsList := Items{}
 		err := json.Unmarshal(data, &itemsList)
 		if err != nil {
-			fmt.Println(err)
 			slog.ErrorContext(ctx, "Load item from json failed", "error", err)
 			return Items{}, err
 		}
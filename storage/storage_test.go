@@ -2,9 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"todo-app/metrics"
 )
 
 // setupTestFile creates a temporary file with the given data and returns its name.
@@ -49,6 +54,27 @@ func TestStorage_CreateItem_EmptyDescription(t *testing.T) {
 	}
 }
 
+// TestStorage_CreateItem_LongDescription tests that descriptions over the length limit are rejected.
+func TestStorage_CreateItem_LongDescription(t *testing.T) {
+	ctx := context.Background()
+	itemsList = Items{}
+	itemsDatafile = setupTestFile(t, "{}")
+	defer os.Remove(itemsDatafile)
+	SetMaxDescriptionLength(5)
+	defer SetMaxDescriptionLength(DefaultMaxDescriptionLength)
+
+	// the limit counts characters, not bytes
+	if _, err := CreateItem(ctx, "héllo", "not_started"); err != nil {
+		t.Errorf("Expected 5 characters to be accepted: %v", err)
+	}
+	if _, err := CreateItem(ctx, "hello!", "not_started"); !errors.Is(err, ErrLongDescription) {
+		t.Errorf("Expected ErrLongDescription, got %v", err)
+	}
+	if _, err := UpdateItem(ctx, Item{ID: 1, Description: "hello!", Status: "not_started"}); !errors.Is(err, ErrLongDescription) {
+		t.Errorf("Expected ErrLongDescription on update, got %v", err)
+	}
+}
+
 // TestStorage_CreateItem_InvalidStatus tests CreateItem with an invalid status.
 func TestStorage_CreateItem_InvalidStatus(t *testing.T) {
 	ctx := context.Background()
@@ -125,6 +151,77 @@ func TestStorage_DeleteItem_InvalidID(t *testing.T) {
 	}
 }
 
+// TestStorage_RestoreItem tests that a deleted item comes back with its ID, owner and creation time,
+// and that an ID in use is not overwritten.
+func TestStorage_RestoreItem(t *testing.T) {
+	ctx := context.Background()
+	itemsList = Items{}
+	itemsDatafile = setupTestFile(t, "{}")
+	defer os.Remove(itemsDatafile)
+
+	CreateItem(ctx, "first", "not_started")
+	item, _ := CreateItemFor(ctx, "alice", "second", "in_progress")
+	CreateItem(ctx, "third", "not_started")
+	if err := DeleteItem(ctx, item.ID); err != nil {
+		t.Fatalf("DeleteItem failed: %v", err)
+	}
+	restored, err := RestoreItem(ctx, item)
+	if err != nil {
+		t.Fatalf("RestoreItem failed: %v", err)
+	}
+	if restored != item || itemsList[item.ID] != item {
+		t.Errorf("expected %+v restored, got %+v", item, itemsList[item.ID])
+	}
+
+	if _, err := RestoreItem(ctx, item); !errors.Is(err, ErrItemExists) {
+		t.Errorf("expected ErrItemExists, got %v", err)
+	}
+	if _, err := RestoreItem(ctx, Item{ID: 0, Description: "x", Status: "not_started"}); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("expected ErrInvalidID, got %v", err)
+	}
+}
+
+// TestStorage_StatusHelpers tests the helpers the front ends share for statuses, IDs and partial updates.
+func TestStorage_StatusHelpers(t *testing.T) {
+	for _, status := range Statuses {
+		if !ValidStatus(status) {
+			t.Errorf("expected %s to be valid", status)
+		}
+	}
+	if ValidStatus("done") || ValidStatus("") {
+		t.Error("expected unknown and empty statuses to be invalid")
+	}
+
+	if id, err := ParseID("12"); err != nil || id != 12 {
+		t.Errorf("ParseID(12) = %d, %v", id, err)
+	}
+	for _, text := range []string{"0", "-1", "x", ""} {
+		if _, err := ParseID(text); !errors.Is(err, ErrInvalidID) || !strings.Contains(err.Error(), strconv.Quote(text)) {
+			t.Errorf("ParseID(%q): expected ErrInvalidID naming the input, got %v", text, err)
+		}
+	}
+
+	items := Items{
+		1: {ID: 1, Status: "not_started"},
+		2: {ID: 2, Status: "in_progress"},
+		3: {ID: 3, Status: "in_progress"},
+	}
+	if got := items.WithStatus("in_progress"); len(got) != 2 || got[1].ID != 0 {
+		t.Errorf("expected items 2 and 3, got %+v", got)
+	}
+	if got := items.WithStatus(""); len(got) != 3 {
+		t.Errorf("expected every item without a status, got %+v", got)
+	}
+
+	item := Item{ID: 1, Description: "Buy milk", Status: "not_started", Owner: "alice"}
+	if got := item.Changed("", "is_finished"); got.Description != "Buy milk" || got.Status != "is_finished" || got.Owner != "alice" {
+		t.Errorf("expected only the status changed, got %+v", got)
+	}
+	if got := item.Changed("Buy oat milk", ""); got.Description != "Buy oat milk" || got.Status != "not_started" {
+		t.Errorf("expected only the description changed, got %+v", got)
+	}
+}
+
 // TestStorage_GetItemByID tests the GetItemByID function.
 func TestStorage_GetItemByID(t *testing.T) {
 	ctx := context.Background()
@@ -209,15 +306,6 @@ func TestStorage_Open(t *testing.T) {
 	}
 }
 
-// TestStorage_ListItem_NoItems tests ListItem when there are no items.
-func TestStorage_ListItem_NoItems(t *testing.T) {
-	itemsList = Items{}
-	err := ListItem(0)
-	if err == nil {
-		t.Error("Expected error for no items to list")
-	}
-}
-
 // TestStorage_HighestKey tests the highestKey function.
 func TestStorage_HighestKey(t *testing.T) {
 	keys := []int{1, 2, 5, 3}
@@ -249,3 +337,97 @@ func TestStorage_NewItem(t *testing.T) {
 		t.Errorf("newItem Created time incorrect")
 	}
 }
+
+// TestStorage_CreateItemFor tests creating an item with an owner.
+func TestStorage_CreateItemFor(t *testing.T) {
+	ctx := context.Background()
+	itemsList = Items{}
+	itemsDatafile = setupTestFile(t, "{}")
+	defer os.Remove(itemsDatafile)
+
+	item, err := CreateItemFor(ctx, "alice", "desc", "not_started")
+	if err != nil {
+		t.Fatalf("CreateItemFor failed: %v", err)
+	}
+	if item.Owner != "alice" || itemsList[item.ID].Owner != "alice" {
+		t.Errorf("CreateItemFor did not set owner: %+v", item)
+	}
+}
+
+// TestStorage_UpdateItem_KeepsOwnerAndCreated tests that updates cannot reset the owner or creation time.
+func TestStorage_UpdateItem_KeepsOwnerAndCreated(t *testing.T) {
+	ctx := context.Background()
+	itemsList = Items{}
+	itemsDatafile = setupTestFile(t, "{}")
+	defer os.Remove(itemsDatafile)
+
+	item, _ := CreateItemFor(ctx, "alice", "desc", "not_started")
+	updated, err := UpdateItem(ctx, Item{ID: item.ID, Description: "updated", Status: "in_progress"})
+	if err != nil {
+		t.Fatalf("UpdateItem failed: %v", err)
+	}
+	if updated.Owner != "alice" || !updated.Created.Equal(item.Created) {
+		t.Errorf("UpdateItem lost owner or created time: %+v", updated)
+	}
+}
+
+// TestStorage_SaveRecordsMetrics tests that saving updates the item counts and file size metrics.
+func TestStorage_SaveRecordsMetrics(t *testing.T) {
+	ctx := context.Background()
+	itemsList = Items{}
+	datafile := setupTestFile(t, "{}")
+	defer os.Remove(datafile)
+	itemsDatafile = datafile
+
+	CreateItem(ctx, "first", "not_started")
+	CreateItem(ctx, "second", "in_progress")
+	CreateItem(ctx, "third", "in_progress")
+
+	var out strings.Builder
+	metrics.Default.WriteText(&out)
+	info, _ := os.Stat(datafile)
+	for _, want := range []string{
+		`todo_items{status="in_progress"} 2`,
+		`todo_items{status="is_finished"} 0`,
+		`todo_items{status="not_started"} 1`,
+		fmt.Sprintf("todo_storage_file_size_bytes %d", info.Size()),
+		"todo_storage_save_duration_seconds_count ",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in metrics:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestStorage_Check tests the data file and last save checks.
+func TestStorage_Check(t *testing.T) {
+	ctx := context.Background()
+	itemsList = Items{}
+	itemsDatafile = ""
+	if health := Check(); !errors.Is(health.File, ErrDataFileNotOpen) {
+		t.Errorf("Expected ErrDataFileNotOpen, got %v", health.File)
+	}
+
+	datafile := setupTestFile(t, "{}")
+	defer os.Remove(datafile)
+	itemsDatafile = datafile
+	if err := Save(ctx, datafile); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if health := Check(); health.File != nil || health.LastSaveErr != nil || health.LastSave.IsZero() {
+		t.Errorf("Expected healthy storage, got %+v", health)
+	}
+
+	// a save into a missing folder fails and is reported
+	if err := Save(ctx, datafile+".missing/todos.json"); err == nil {
+		t.Fatal("Expected Save into a missing folder to fail")
+	}
+	if health := Check(); health.LastSaveErr == nil {
+		t.Error("Expected the failed save to be reported")
+	}
+
+	os.Remove(datafile)
+	if health := Check(); !errors.Is(health.File, ErrDataFileNotReadable) {
+		t.Errorf("Expected ErrDataFileNotReadable, got %v", health.File)
+	}
+}
@@ -0,0 +1,64 @@
+package terminal
+
+import "bufio"
+
+// ReadKey reads one key press, naming special keys ("up", "enter", "ctrl-c") and returning other keys as typed.
+func ReadKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case '\t':
+		return "tab", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	case 0x04:
+		return "ctrl-d", nil
+	case 0x15:
+		return "ctrl-u", nil
+	case 0x1b:
+		// a lone escape is the Esc key, otherwise it starts a sequence such as "\x1b[A"
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		return readEscape(r)
+	}
+	return string(c), nil
+}
+
+// escapes names the escape sequences of the special keys, without the leading escape.
+var escapes = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[3~": "delete", "[4~": "end", "[5~": "pgup", "[6~": "pgdn",
+}
+
+// readEscape reads the rest of an escape sequence; unknown sequences are read whole and ignored.
+func readEscape(r *bufio.Reader) (string, error) {
+	intro, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if intro != '[' && intro != 'O' {
+		return "esc", nil
+	}
+	seq := []byte{intro}
+	for r.Buffered() > 0 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq = append(seq, c)
+		// parameters are digits and ';', the final byte ends the sequence
+		if c >= 0x40 && c <= 0x7e {
+			break
+		}
+	}
+	return escapes[string(seq)], nil
+}
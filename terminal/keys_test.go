@@ -0,0 +1,25 @@
+package terminal
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestTerminal_ReadKey tests decoding of special keys and escape sequences.
+func TestTerminal_ReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("a\r\t\x7f\x03\x04\x1b[3~\x1b[B\x1bOA\x1b[5~\x1b[99Zé"))
+	var keys []string
+	for {
+		key, err := ReadKey(r)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+	want := []string{"a", "enter", "tab", "backspace", "ctrl-c", "ctrl-d", "delete", "down", "up", "pgup", "", "é"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("readKey = %q, want %q", keys, want)
+	}
+}
@@ -0,0 +1,9 @@
+package terminal
+
+import "syscall"
+
+// ioctl requests to read and change the terminal settings
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
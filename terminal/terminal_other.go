@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !windows
+
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("terminal mode is not supported on this system")
+
+// MakeRaw always fails, as raw mode is only implemented for Linux, macOS and Windows.
+func MakeRaw(in *os.File, out *os.File) (func() error, error) {
+	return nil, errUnsupported
+}
+
+// Size always fails, see MakeRaw.
+func Size(out *os.File) (int, int, error) {
+	return 0, 0, errUnsupported
+}
@@ -0,0 +1,79 @@
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// console modes, see https://learn.microsoft.com/windows/console/setconsolemode
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalInput      = 0x0200
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+type coord struct {
+	x, y int16
+}
+
+type smallRect struct {
+	left, top, right, bottom int16
+}
+
+type consoleScreenBufferInfo struct {
+	size              coord
+	cursorPosition    coord
+	attributes        uint16
+	window            smallRect
+	maximumWindowSize coord
+}
+
+// MakeRaw switches the console to reading single key presses as ANSI sequences without echo,
+// and to interpreting ANSI escape codes in output, and returns how to switch it back.
+func MakeRaw(in *os.File, out *os.File) (func() error, error) {
+	inHandle, outHandle := syscall.Handle(in.Fd()), syscall.Handle(out.Fd())
+	var oldIn, oldOut uint32
+	if err := syscall.GetConsoleMode(inHandle, &oldIn); err != nil {
+		return nil, err
+	}
+	if err := syscall.GetConsoleMode(outHandle, &oldOut); err != nil {
+		return nil, err
+	}
+	rawIn := oldIn&^(enableEchoInput|enableLineInput|enableProcessedInput) | enableVirtualTerminalInput
+	if err := setConsoleMode(inHandle, rawIn); err != nil {
+		return nil, err
+	}
+	if err := setConsoleMode(outHandle, oldOut|enableVirtualTerminalProcessing); err != nil {
+		setConsoleMode(inHandle, oldIn)
+		return nil, err
+	}
+	return func() error {
+		setConsoleMode(outHandle, oldOut)
+		return setConsoleMode(inHandle, oldIn)
+	}, nil
+}
+
+// Size returns the width and height of the console window in characters.
+func Size(out *os.File) (int, int, error) {
+	var info consoleScreenBufferInfo
+	if ok, _, err := procGetConsoleScreenBufferInfo.Call(out.Fd(), uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return 0, 0, err
+	}
+	return int(info.window.right-info.window.left) + 1, int(info.window.bottom-info.window.top) + 1, nil
+}
+
+func setConsoleMode(handle syscall.Handle, mode uint32) error {
+	if ok, _, err := procSetConsoleMode.Call(uintptr(handle), uintptr(mode)); ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package tlscert
+
+import (
+	"bytes"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTLSCert_EnsureSelfSigned tests that a usable certificate is generated once and then reused.
+func TestTLSCert_EnsureSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	if err := EnsureSelfSigned(certFile, keyFile, LocalHosts); err != nil {
+		t.Fatalf("EnsureSelfSigned failed: %v", err)
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair failed: %v", err)
+	}
+	if err := pair.Leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("Expected certificate for localhost: %v", err)
+	}
+	if err := pair.Leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("Expected certificate for 127.0.0.1: %v", err)
+	}
+	if info, _ := os.Stat(keyFile); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	first, _ := os.ReadFile(certFile)
+	if err := EnsureSelfSigned(certFile, keyFile, LocalHosts); err != nil {
+		t.Fatalf("EnsureSelfSigned failed: %v", err)
+	}
+	second, _ := os.ReadFile(certFile)
+	if !bytes.Equal(first, second) {
+		t.Error("Expected the existing certificate to be reused")
+	}
+}
@@ -0,0 +1,326 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"todo-app/storage"
+	"todo-app/terminal"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNotTerminal is returned by Run when stdin or stdout is not an interactive terminal.
+var ErrNotTerminal = errors.New("not a terminal")
+
+// Service is the part of the actor's method set the interface uses, so it runs against the data file or a server.
+type Service interface {
+	ListAll(ctx context.Context) (storage.Items, error)
+	Update(ctx context.Context, id int, description string, status string) (storage.Item, error)
+}
+
+// statusMarks are shown in front of each item for its status.
+var statusMarks = map[string]string{"not_started": "[ ]", "in_progress": "[~]", "is_finished": "[x]"}
+
+// ANSI escape sequences
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // alternate screen, hide cursor
+	leaveScreen = "\x1b[?25h\x1b[?1049l" // show cursor, main screen
+	home        = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+	bold        = "\x1b[1m"
+	dim         = "\x1b[2m"
+	reverse     = "\x1b[7m"
+	reset       = "\x1b[0m"
+)
+
+// help is the footer shown while browsing.
+const help = "↑/↓ move  space status  e edit  s filter status  t filter tag  r reload  q quit"
+
+// Run shows the full screen interface on the terminal until the user quits.
+// Changes are saved through items as they are made.
+func Run(ctx context.Context, items Service, in *os.File, out *os.File) error {
+	restore, err := terminal.MakeRaw(in, out)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotTerminal, err)
+	}
+	defer restore()
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, leaveScreen)
+	return run(ctx, items, in, out, func() (int, int) {
+		width, height, err := terminal.Size(out)
+		if err != nil || width <= 0 || height <= 0 {
+			return 80, 24
+		}
+		return width, height
+	})
+}
+
+// run draws the screen and handles keys from in until the user quits or in ends.
+func run(ctx context.Context, items Service, in io.Reader, out io.Writer, size func() (int, int)) error {
+	a := &app{ctx: ctx, items: items}
+	a.refresh()
+	keys := bufio.NewReader(in)
+	screen := bufio.NewWriter(out)
+	for !a.quit {
+		width, height := size()
+		a.draw(screen, width, height)
+		if err := screen.Flush(); err != nil {
+			return err
+		}
+		key, err := terminal.ReadKey(keys)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a.handle(key, height)
+	}
+	return nil
+}
+
+// mode is what keys currently do.
+type mode int
+
+const (
+	browsing mode = iota
+	editing
+	tagging
+)
+
+// app is the state of the interface between key presses.
+type app struct {
+	ctx   context.Context
+	items Service
+
+	all    storage.Items
+	shown  []storage.Item
+	cursor int
+	top    int
+
+	status string // only items with this status are shown, all when empty
+	tag    string // only items with this tag are shown, all when empty
+
+	mode    mode
+	input   []rune
+	message string
+	quit    bool
+}
+
+// selected returns the item under the cursor.
+func (a *app) selected() (storage.Item, bool) {
+	if a.cursor < 0 || a.cursor >= len(a.shown) {
+		return storage.Item{}, false
+	}
+	return a.shown[a.cursor], true
+}
+
+// refresh reloads the items and applies the filters, keeping the cursor on the same item where it is still shown.
+func (a *app) refresh() {
+	current, hadCurrent := a.selected()
+	all, err := a.items.ListAll(a.ctx)
+	if err != nil && !errors.Is(err, storage.ErrNoItems) {
+		a.message = "Reload failed: " + err.Error()
+		return
+	}
+	a.all = all
+	a.filter()
+	if hadCurrent {
+		if i := slices.IndexFunc(a.shown, func(item storage.Item) bool { return item.ID == current.ID }); i >= 0 {
+			a.cursor = i
+		}
+	}
+	a.cursor = max(0, min(a.cursor, len(a.shown)-1))
+}
+
+// filter sets shown to the items matching the status and tag filters, ordered by ID.
+func (a *app) filter() {
+	a.shown = a.shown[:0]
+	for _, item := range a.all {
+		if a.status != "" && item.Status != a.status {
+			continue
+		}
+		if a.tag != "" && !slices.Contains(Tags(item.Description), a.tag) {
+			continue
+		}
+		a.shown = append(a.shown, item)
+	}
+	slices.SortFunc(a.shown, func(x, y storage.Item) int { return x.ID - y.ID })
+}
+
+// update saves a changed item and reloads the list.
+func (a *app) update(item storage.Item, done string) {
+	if _, err := a.items.Update(a.ctx, item.ID, item.Description, item.Status); err != nil {
+		a.message = fmt.Sprintf("Item %d not saved: %v", item.ID, err)
+		return
+	}
+	a.message = done
+	a.refresh()
+}
+
+// handle applies one key press; height is the screen height, for paging.
+func (a *app) handle(key string, height int) {
+	switch a.mode {
+	case editing, tagging:
+		a.handleInput(key)
+		return
+	}
+	a.message = ""
+	page := max(1, height-2)
+	switch key {
+	case "q", "ctrl-c":
+		a.quit = true
+	case "up", "k":
+		a.cursor--
+	case "down", "j":
+		a.cursor++
+	case "pgup":
+		a.cursor -= page
+	case "pgdn":
+		a.cursor += page
+	case "home", "g":
+		a.cursor = 0
+	case "end", "G":
+		a.cursor = len(a.shown) - 1
+	case " ", "x":
+		if item, ok := a.selected(); ok {
+			item.Status = nextStatus(item.Status)
+			a.update(item, fmt.Sprintf("Item %d is %s", item.ID, item.Status))
+		}
+	case "e", "enter":
+		if item, ok := a.selected(); ok {
+			a.mode, a.input = editing, []rune(item.Description)
+		}
+	case "s":
+		a.status = nextFilter(a.status)
+		a.filter()
+	case "t":
+		a.mode, a.input = tagging, []rune(a.tag)
+	case "r":
+		a.refresh()
+	}
+	a.cursor = max(0, min(a.cursor, len(a.shown)-1))
+}
+
+// handleInput edits the line being typed, for a description or a tag filter.
+func (a *app) handleInput(key string) {
+	switch key {
+	case "esc", "ctrl-c":
+		a.mode, a.input = browsing, nil
+	case "enter":
+		text := strings.TrimSpace(string(a.input))
+		if a.mode == tagging {
+			a.tag = strings.ToLower(strings.TrimPrefix(text, "#"))
+			a.filter()
+			a.cursor = 0
+		} else if item, ok := a.selected(); ok && text != item.Description {
+			item.Description = text
+			a.update(item, fmt.Sprintf("Item %d saved", item.ID))
+		}
+		a.mode, a.input = browsing, nil
+	case "backspace":
+		if len(a.input) > 0 {
+			a.input = a.input[:len(a.input)-1]
+		}
+	case "ctrl-u":
+		a.input = a.input[:0]
+	default:
+		if r, size := utf8.DecodeRuneInString(key); size == len(key) && unicode.IsPrint(r) {
+			a.input = append(a.input, r)
+		}
+	}
+}
+
+// draw writes the whole screen: a title line, the items and a footer.
+func (a *app) draw(w io.Writer, width int, height int) {
+	rows := max(1, height-2)
+	if a.cursor < a.top {
+		a.top = a.cursor
+	}
+	if a.cursor >= a.top+rows {
+		a.top = a.cursor - rows + 1
+	}
+
+	title := fmt.Sprintf("Todo-App  %d of %d items", len(a.shown), len(a.all))
+	if a.status != "" {
+		title += "  status: " + a.status
+	}
+	if a.tag != "" {
+		title += "  tag: #" + a.tag
+	}
+	fmt.Fprint(w, home, bold, truncate(title, width), reset, clearLine, "\r\n")
+
+	for row := range rows {
+		i := a.top + row
+		switch {
+		case i < len(a.shown):
+			item := a.shown[i]
+			description := item.Description
+			if i == a.cursor && a.mode == editing {
+				description = string(a.input) + "▏"
+			}
+			line := truncate(fmt.Sprintf(" %s %4d  %s", statusMarks[item.Status], item.ID, description), width)
+			if i == a.cursor {
+				fmt.Fprint(w, reverse, line, strings.Repeat(" ", max(0, width-utf8.RuneCountInString(line))), reset)
+			} else {
+				fmt.Fprint(w, line)
+			}
+		case i == 0:
+			fmt.Fprint(w, dim, " No items", reset)
+		}
+		fmt.Fprint(w, clearLine, "\r\n")
+	}
+
+	footer := help
+	switch {
+	case a.mode == editing:
+		footer = "Enter save  Esc cancel  Ctrl-U clear"
+	case a.mode == tagging:
+		footer = "Tag (empty shows all): #" + string(a.input) + "▏"
+	case a.message != "":
+		footer = a.message
+	}
+	fmt.Fprint(w, dim, truncate(footer, width), reset, clearLine, clearBelow)
+}
+
+// Tags returns the lower case #tags in a description, without the #.
+func Tags(description string) []string {
+	var tags []string
+	for _, word := range strings.Fields(description) {
+		tag := strings.TrimRightFunc(strings.TrimPrefix(word, "#"), unicode.IsPunct)
+		if strings.HasPrefix(word, "#") && tag != "" {
+			tags = append(tags, strings.ToLower(tag))
+		}
+	}
+	return tags
+}
+
+// nextStatus returns the status after status, cycling through storage.Statuses.
+func nextStatus(status string) string {
+	i := slices.Index(storage.Statuses, status)
+	return storage.Statuses[(i+1)%len(storage.Statuses)]
+}
+
+// nextFilter returns the status filter after filter, going through every status and then back to all.
+func nextFilter(filter string) string {
+	i := slices.Index(storage.Statuses, filter)
+	if i == len(storage.Statuses)-1 {
+		return ""
+	}
+	return storage.Statuses[i+1]
+}
+
+// truncate cuts s to at most width characters.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:max(0, width)])
+}
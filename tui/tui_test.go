@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"todo-app/internal/itemstest"
+	"todo-app/storage"
+)
+
+// newFake returns a fake with an item of each status, some with tags.
+func newFake() *itemstest.Fake {
+	return itemstest.New(
+		storage.Item{ID: 1, Description: "Buy milk #shop", Status: "not_started"},
+		storage.Item{ID: 2, Description: "Fix the bike", Status: "in_progress"},
+		storage.Item{ID: 3, Description: "Buy stamps #Shop, #post", Status: "is_finished"},
+	)
+}
+
+// runKeys runs the interface with the keys typed and returns the last screen drawn.
+func runKeys(t *testing.T, items Service, keys string) string {
+	t.Helper()
+	var out strings.Builder
+	if err := run(context.Background(), items, strings.NewReader(keys), &out, func() (int, int) { return 60, 10 }); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	screens := strings.Split(out.String(), home)
+	return screens[len(screens)-1]
+}
+
+// TestTUI_ToggleStatus tests that space cycles the status of the item under the cursor and saves it.
+func TestTUI_ToggleStatus(t *testing.T) {
+	fake := newFake()
+	screen := runKeys(t, fake, "j  \x1b[A q")
+	if got := fake.Items[2].Status; got != "not_started" {
+		t.Errorf("expected item 2 cycled twice to not_started, got %s", got)
+	}
+	if got := fake.Items[1].Status; got != "in_progress" {
+		t.Errorf("expected item 1 in_progress after moving up, got %s", got)
+	}
+	if !strings.Contains(screen, "[~]    1  Buy milk") {
+		t.Errorf("expected the new status on screen:\n%s", screen)
+	}
+}
+
+// TestTUI_Edit tests inline editing, cancelling and rejected descriptions.
+func TestTUI_Edit(t *testing.T) {
+	fake := newFake()
+	runKeys(t, fake, "e\x7f\x7f\x7f\x7f\x7f\x7f coffee\r")
+	if got := fake.Items[1].Description; got != "Buy milk coffee" {
+		t.Errorf("expected the edited description, got %q", got)
+	}
+
+	runKeys(t, fake, "eabc\x1b")
+	if got := fake.Items[1].Description; got != "Buy milk coffee" {
+		t.Errorf("expected Esc to cancel the edit, got %q", got)
+	}
+
+	screen := runKeys(t, fake, "e\x15\r")
+	if !strings.Contains(screen, "Item 1 not saved: description cannot be empty") {
+		t.Errorf("expected the storage error in the footer:\n%s", screen)
+	}
+}
+
+// TestTUI_Filters tests filtering by status and by tag.
+func TestTUI_Filters(t *testing.T) {
+	screen := runKeys(t, newFake(), "ss")
+	if !strings.Contains(screen, "1 of 3 items  status: in_progress") || !strings.Contains(screen, "Fix the bike") || strings.Contains(screen, "Buy milk") {
+		t.Errorf("expected only in_progress items:\n%s", screen)
+	}
+
+	screen = runKeys(t, newFake(), "t#SHOP\r")
+	if !strings.Contains(screen, "2 of 3 items  tag: #shop") || strings.Contains(screen, "Fix the bike") {
+		t.Errorf("expected only #shop items:\n%s", screen)
+	}
+
+	// toggling the only shown item out of the filter leaves an empty list
+	fake := newFake()
+	screen = runKeys(t, fake, "s ")
+	if !strings.Contains(screen, "No items") || fake.Items[1].Status != "in_progress" {
+		t.Errorf("expected an empty list after the item left the filter:\n%s", screen)
+	}
+}
+
+// TestTUI_Scroll tests that the list scrolls to keep the cursor on screen.
+func TestTUI_Scroll(t *testing.T) {
+	fake := itemstest.New()
+	for id := 1; id <= 20; id++ {
+		fake.Items[id] = storage.Item{ID: id, Description: "Item", Status: "not_started"}
+	}
+	screen := runKeys(t, fake, "\x1b[F")
+	if !strings.Contains(screen, "  20  Item") || strings.Contains(screen, "   1  Item") {
+		t.Errorf("expected the end of the list on screen:\n%s", screen)
+	}
+}
+
+// TestTUI_Tags tests that tags are the #words of a description.
+func TestTUI_Tags(t *testing.T) {
+	if got := Tags("Buy #Milk, eggs #x # and#not #home!"); !slices.Equal(got, []string{"milk", "x", "home"}) {
+		t.Errorf("unexpected tags %q", got)
+	}
+}
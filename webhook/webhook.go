@@ -0,0 +1,479 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+	"todo-app/events"
+)
+
+const (
+	hooksFile string = "webhooks.json"
+	queueFile string = "webhook_queue.json"
+	logFile   string = "webhook_log.json"
+)
+
+const (
+	SignatureHeader string = "X-Todo-Signature"
+	EventHeader     string = "X-Todo-Event"
+	DeliveryHeader  string = "X-Todo-Delivery"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a delivery is tried before it is dropped.
+	DefaultMaxAttempts int = 8
+	// DefaultBaseBackoff is the delay before the first retry; it doubles on each further attempt.
+	DefaultBaseBackoff time.Duration = 2 * time.Second
+	// maxBackoff caps the delay between attempts.
+	maxBackoff time.Duration = 10 * time.Minute
+	// maxLogEntries bounds the persisted delivery log.
+	maxLogEntries int = 500
+)
+
+var (
+	ErrHookNotFound = errors.New("webhook not found")
+	ErrInvalidURL   = errors.New("webhook url must be an absolute http or https url")
+)
+
+type Hook struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret"`
+	Events  []string  `json:"events,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type Delivery struct {
+	ID          string       `json:"id"`
+	HookID      string       `json:"hook_id"`
+	Event       events.Event `json:"event"`
+	Attempts    int          `json:"attempts"`
+	NextAttempt time.Time    `json:"next_attempt"`
+}
+
+type LogEntry struct {
+	DeliveryID string    `json:"delivery_id"`
+	HookID     string    `json:"hook_id"`
+	URL        string    `json:"url"`
+	EventID    uint64    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Delivered  bool      `json:"delivered"`
+	Dropped    bool      `json:"dropped,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+type Dispatcher struct {
+	mu          sync.Mutex
+	folder      string
+	hooks       map[string]Hook
+	queue       []Delivery
+	log         []LogEntry
+	wake        chan struct{}
+	Client      *http.Client
+	MaxAttempts int
+	BaseBackoff time.Duration
+}
+
+// NewDispatcher loads the registered hooks, pending deliveries and delivery log from the data folder.
+func NewDispatcher(ctx context.Context, folder string) (*Dispatcher, error) {
+	d := &Dispatcher{
+		folder:      folder,
+		hooks:       map[string]Hook{},
+		wake:        make(chan struct{}, 1),
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+	}
+
+	var hooks []Hook
+	if err := readJSON(d.path(hooksFile), &hooks); err != nil {
+		slog.ErrorContext(ctx, "Load webhooks failed", "error", err, "file", d.path(hooksFile))
+		return nil, err
+	}
+	for _, hook := range hooks {
+		d.hooks[hook.ID] = hook
+	}
+	if err := readJSON(d.path(queueFile), &d.queue); err != nil {
+		slog.ErrorContext(ctx, "Load webhook queue failed", "error", err, "file", d.path(queueFile))
+		return nil, err
+	}
+	if err := readJSON(d.path(logFile), &d.log); err != nil {
+		slog.ErrorContext(ctx, "Load webhook log failed", "error", err, "file", d.path(logFile))
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Loaded webhooks", "hooks", len(d.hooks), "pending", len(d.queue))
+	return d, nil
+}
+
+// Register adds a new hook; a random secret is generated when none is given.
+func (d *Dispatcher) Register(ctx context.Context, rawURL string, secret string, eventTypes []string) (Hook, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return Hook{}, ErrInvalidURL
+	}
+	if secret == "" {
+		secret = randomID(32)
+	}
+	hook := Hook{ID: randomID(8), URL: rawURL, Secret: secret, Events: eventTypes, Created: time.Now().UTC()}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks[hook.ID] = hook
+	if err := d.saveHooks(); err != nil {
+		delete(d.hooks, hook.ID)
+		return Hook{}, err
+	}
+	slog.InfoContext(ctx, "Registered webhook", "ID", hook.ID, "URL", hook.URL)
+	return hook, nil
+}
+
+// Unregister removes a hook and drops its pending deliveries.
+func (d *Dispatcher) Unregister(ctx context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hook, ok := d.hooks[id]
+	if !ok {
+		return ErrHookNotFound
+	}
+	delete(d.hooks, id)
+	if err := d.saveHooks(); err != nil {
+		d.hooks[id] = hook
+		return err
+	}
+	d.queue = slices.DeleteFunc(d.queue, func(delivery Delivery) bool { return delivery.HookID == id })
+	d.saveQueue(ctx)
+	slog.InfoContext(ctx, "Unregistered webhook", "ID", id)
+	return nil
+}
+
+// Hooks returns the registered hooks ordered by creation time.
+func (d *Dispatcher) Hooks() []Hook {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hooks := make([]Hook, 0, len(d.hooks))
+	for _, hook := range d.hooks {
+		hooks = append(hooks, hook)
+	}
+	slices.SortFunc(hooks, func(a, b Hook) int { return a.Created.Compare(b.Created) })
+	return hooks
+}
+
+// Pending returns a copy of the deliveries still waiting to be sent.
+func (d *Dispatcher) Pending() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.queue)
+}
+
+// Log returns a copy of the delivery log, oldest first.
+func (d *Dispatcher) Log() []LogEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.log)
+}
+
+// Enqueue queues a delivery of the event to every hook subscribed to its type.
+func (d *Dispatcher) Enqueue(ctx context.Context, event events.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	queued := 0
+	for _, hook := range d.hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, event.Type) {
+			continue
+		}
+		d.queue = append(d.queue, Delivery{ID: randomID(8), HookID: hook.ID, Event: event, NextAttempt: time.Now()})
+		queued++
+	}
+	if queued == 0 {
+		return
+	}
+	d.saveQueue(ctx)
+
+	// nudge the worker without blocking
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run consumes the broker's change feed and delivers queued events until ctx is cancelled.
+// It returns once the delivery in flight, if any, has been recorded.
+func (d *Dispatcher) Run(ctx context.Context, broker *events.Broker) {
+	delivering := make(chan struct{})
+	go func() {
+		defer close(delivering)
+		d.deliverLoop(ctx)
+	}()
+	defer func() { <-delivering }()
+
+	// start from the broker's current ID, so a resubscribe before any event arrived still replays from here
+	lastID := broker.LastID()
+	for {
+		backlog, ch, complete := broker.Subscribe(lastID)
+		if !complete {
+			slog.WarnContext(ctx, "Webhook feed missed events dropped from the broker buffer", "LastID", lastID)
+		}
+		for _, event := range backlog {
+			d.Enqueue(ctx, event)
+			lastID = event.ID
+		}
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				// queue the events already published, they are delivered after a restart
+				for {
+					select {
+					case event, ok := <-ch:
+						if ok {
+							d.Enqueue(ctx, event)
+							continue
+						}
+					default:
+					}
+					broker.Unsubscribe(ch)
+					return
+				}
+			case event, ok := <-ch:
+				if !ok {
+					// dropped as a slow consumer, resubscribe from the last event seen
+					open = false
+					break
+				}
+				d.Enqueue(ctx, event)
+				lastID = event.ID
+			}
+		}
+	}
+}
+
+// deliverLoop sends due deliveries and sleeps until the next one is due or new work arrives.
+func (d *Dispatcher) deliverLoop(ctx context.Context) {
+	for {
+		wait := d.DeliverDue(ctx)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery whose next attempt time has passed
+// and returns how long to wait until the next one is due.
+func (d *Dispatcher) DeliverDue(ctx context.Context) time.Duration {
+	for {
+		delivery, hook, ok := d.nextDue()
+		if !ok {
+			break
+		}
+		statusCode, err := d.send(ctx, hook, delivery)
+		d.complete(ctx, hook, delivery, statusCode, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wait := time.Minute
+	for _, delivery := range d.queue {
+		if until := time.Until(delivery.NextAttempt); until < wait {
+			wait = max(until, 0)
+		}
+	}
+	return wait
+}
+
+// nextDue returns the first delivery ready to be attempted.
+func (d *Dispatcher) nextDue() (Delivery, Hook, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// forget deliveries loaded from the queue file for hooks that were removed from webhooks.json
+	// while the server was stopped; the file is only read at startup
+	d.queue = slices.DeleteFunc(d.queue, func(delivery Delivery) bool {
+		_, ok := d.hooks[delivery.HookID]
+		return !ok
+	})
+
+	now := time.Now()
+	for _, delivery := range d.queue {
+		if delivery.NextAttempt.After(now) {
+			continue
+		}
+		if hook, ok := d.hooks[delivery.HookID]; ok {
+			return delivery, hook, true
+		}
+	}
+	return Delivery{}, Hook{}, false
+}
+
+// send posts the signed event payload to the hook.
+func (d *Dispatcher) send(ctx context.Context, hook Hook, delivery Delivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// complete records the outcome of an attempt and reschedules or removes the delivery.
+func (d *Dispatcher) complete(ctx context.Context, hook Hook, delivery Delivery, statusCode int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delivery.Attempts++
+	entry := LogEntry{
+		DeliveryID: delivery.ID,
+		HookID:     hook.ID,
+		URL:        hook.URL,
+		EventID:    delivery.Event.ID,
+		EventType:  delivery.Event.Type,
+		Attempt:    delivery.Attempts,
+		StatusCode: statusCode,
+		Delivered:  err == nil,
+		Time:       time.Now().UTC(),
+	}
+
+	index := slices.IndexFunc(d.queue, func(queued Delivery) bool { return queued.ID == delivery.ID })
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "Delivered webhook", "ID", delivery.ID, "URL", hook.URL, "Attempt", delivery.Attempts)
+		if index >= 0 {
+			d.queue = slices.Delete(d.queue, index, index+1)
+		}
+	case delivery.Attempts >= d.MaxAttempts:
+		entry.Error = err.Error()
+		entry.Dropped = true
+		slog.ErrorContext(ctx, "Dropped webhook delivery after max attempts", "ID", delivery.ID, "URL", hook.URL, "error", err)
+		if index >= 0 {
+			d.queue = slices.Delete(d.queue, index, index+1)
+		}
+	default:
+		entry.Error = err.Error()
+		delivery.NextAttempt = time.Now().Add(d.backoff(delivery.Attempts))
+		slog.WarnContext(ctx, "Webhook delivery failed, will retry", "ID", delivery.ID, "URL", hook.URL, "Attempt", delivery.Attempts, "NextAttempt", delivery.NextAttempt, "error", err)
+		if index >= 0 {
+			d.queue[index] = delivery
+		}
+	}
+
+	d.log = append(d.log, entry)
+	if len(d.log) > maxLogEntries {
+		d.log = slices.Clone(d.log[len(d.log)-maxLogEntries:])
+	}
+	d.saveQueue(ctx)
+	if err := writeJSON(d.path(logFile), d.log); err != nil {
+		slog.ErrorContext(ctx, "Save webhook log failed", "error", err)
+	}
+}
+
+// backoff returns the exponential delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Sign returns the signature header value for a payload: "sha256=" followed by the hex HMAC-SHA256.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body, for use by receivers.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// saveHooks persists the registered hooks; callers hold the lock.
+func (d *Dispatcher) saveHooks() error {
+	hooks := make([]Hook, 0, len(d.hooks))
+	for _, hook := range d.hooks {
+		hooks = append(hooks, hook)
+	}
+	slices.SortFunc(hooks, func(a, b Hook) int { return a.Created.Compare(b.Created) })
+	return writeJSON(d.path(hooksFile), hooks)
+}
+
+// saveQueue persists the pending deliveries; callers hold the lock.
+func (d *Dispatcher) saveQueue(ctx context.Context) {
+	if err := writeJSON(d.path(queueFile), d.queue); err != nil {
+		slog.ErrorContext(ctx, "Save webhook queue failed", "error", err)
+	}
+}
+
+// path returns the location of a webhook file in the data folder.
+func (d *Dispatcher) path(name string) string {
+	return d.folder + "\\" + name
+}
+
+// readJSON decodes a json file into v, leaving v untouched when the file does not exist yet.
+func readJSON(fileName string, v any) error {
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(data) == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON atomically replaces a json file with the encoding of v.
+func writeJSON(fileName string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := fileName + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fileName)
+}
+
+// randomID returns n random bytes as a hex string.
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,340 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"todo-app/events"
+	"todo-app/storage"
+)
+
+// receiver records the requests sent to an httptest webhook endpoint.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+// newReceiver starts a test endpoint that fails the first failures requests with a 500.
+func newReceiver(t *testing.T, failures int) (*receiver, *httptest.Server) {
+	rec := &receiver{failures: failures}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if rec.failures > 0 {
+			rec.failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rec.bodies = append(rec.bodies, body)
+		rec.headers = append(rec.headers, r.Header.Clone())
+	}))
+	t.Cleanup(server.Close)
+	return rec, server
+}
+
+// newTestDispatcher creates a dispatcher in a temp folder with fast retries.
+func newTestDispatcher(t *testing.T, folder string) *Dispatcher {
+	d, err := NewDispatcher(context.Background(), folder)
+	if err != nil {
+		t.Fatalf("NewDispatcher failed: %v", err)
+	}
+	d.BaseBackoff = time.Millisecond
+	return d
+}
+
+// drain keeps delivering until the queue is empty or a second has passed.
+func drain(ctx context.Context, d *Dispatcher) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		wait := d.DeliverDue(ctx)
+		if len(d.Pending()) == 0 {
+			return
+		}
+		time.Sleep(wait)
+	}
+}
+
+// TestWebhook_SignAndVerify tests the HMAC signature helpers.
+func TestWebhook_SignAndVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	signature := Sign("secret", body)
+	if !Verify("secret", body, signature) {
+		t.Error("Expected signature to verify")
+	}
+	if Verify("other", body, signature) {
+		t.Error("Expected signature with wrong secret to fail")
+	}
+}
+
+// TestWebhook_RegisterInvalidURL tests that only absolute http urls are accepted.
+func TestWebhook_RegisterInvalidURL(t *testing.T) {
+	d := newTestDispatcher(t, t.TempDir())
+	for _, rawURL := range []string{"", "ftp://example.com", "/relative"} {
+		if _, err := d.Register(context.Background(), rawURL, "", nil); err != ErrInvalidURL {
+			t.Errorf("Expected ErrInvalidURL for %q, got %v", rawURL, err)
+		}
+	}
+}
+
+// TestWebhook_DeliverSigned tests that deliveries are signed and logged.
+func TestWebhook_DeliverSigned(t *testing.T) {
+	ctx := context.Background()
+	rec, server := newReceiver(t, 0)
+	d := newTestDispatcher(t, t.TempDir())
+	hook, err := d.Register(ctx, server.URL, "", nil)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	d.Enqueue(ctx, events.Event{ID: 1, Type: events.ItemCreated, Item: storage.Item{ID: 1, Description: "Hook"}})
+	d.DeliverDue(ctx)
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(rec.bodies))
+	}
+	if !Verify(hook.Secret, rec.bodies[0], rec.headers[0].Get(SignatureHeader)) {
+		t.Error("Expected delivery signature to verify")
+	}
+	if rec.headers[0].Get(EventHeader) != events.ItemCreated {
+		t.Errorf("Unexpected event header: %s", rec.headers[0].Get(EventHeader))
+	}
+	if len(d.Pending()) != 0 {
+		t.Errorf("Expected empty queue, got %d", len(d.Pending()))
+	}
+	if log := d.Log(); len(log) != 1 || !log[0].Delivered {
+		t.Errorf("Unexpected delivery log: %+v", log)
+	}
+}
+
+// TestWebhook_RetryWithBackoff tests that failed deliveries are retried until they succeed.
+func TestWebhook_RetryWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	rec, server := newReceiver(t, 2)
+	d := newTestDispatcher(t, t.TempDir())
+	if _, err := d.Register(ctx, server.URL, "secret", nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	d.Enqueue(ctx, events.Event{ID: 1, Type: events.ItemUpdated})
+	drain(ctx, d)
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("Expected 1 successful delivery, got %d", len(rec.bodies))
+	}
+	log := d.Log()
+	if len(log) != 3 || log[0].StatusCode != http.StatusInternalServerError || !log[2].Delivered || log[2].Attempt != 3 {
+		t.Errorf("Unexpected delivery log: %+v", log)
+	}
+}
+
+// TestWebhook_DropAfterMaxAttempts tests that deliveries are dropped after too many failures.
+func TestWebhook_DropAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	_, server := newReceiver(t, 100)
+	d := newTestDispatcher(t, t.TempDir())
+	d.MaxAttempts = 2
+	if _, err := d.Register(ctx, server.URL, "secret", nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	d.Enqueue(ctx, events.Event{ID: 1, Type: events.ItemDeleted})
+	drain(ctx, d)
+
+	log := d.Log()
+	if len(d.Pending()) != 0 || len(log) != 2 || !log[1].Dropped {
+		t.Errorf("Expected delivery to be dropped, log: %+v", log)
+	}
+}
+
+// TestWebhook_EventFilter tests that hooks only receive subscribed event types.
+func TestWebhook_EventFilter(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDispatcher(t, t.TempDir())
+	if _, err := d.Register(ctx, "http://example.com/hook", "", []string{events.ItemDeleted}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	d.Enqueue(ctx, events.Event{ID: 1, Type: events.ItemCreated})
+	if len(d.Pending()) != 0 {
+		t.Errorf("Expected filtered event to be skipped")
+	}
+	d.Enqueue(ctx, events.Event{ID: 2, Type: events.ItemDeleted})
+	if len(d.Pending()) != 1 {
+		t.Errorf("Expected subscribed event to be queued")
+	}
+}
+
+// TestWebhook_QueueSurvivesRestart tests that hooks and pending deliveries are reloaded from disk.
+func TestWebhook_QueueSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	folder := t.TempDir()
+	rec, server := newReceiver(t, 0)
+
+	first := newTestDispatcher(t, folder)
+	if _, err := first.Register(ctx, server.URL, "secret", nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	first.Enqueue(ctx, events.Event{ID: 7, Type: events.ItemCreated})
+
+	// a new dispatcher picks up the undelivered event
+	second := newTestDispatcher(t, folder)
+	if len(second.Hooks()) != 1 || len(second.Pending()) != 1 {
+		t.Fatalf("Expected 1 hook and 1 pending delivery, got %d and %d", len(second.Hooks()), len(second.Pending()))
+	}
+	second.DeliverDue(ctx)
+	if len(rec.bodies) != 1 {
+		t.Errorf("Expected reloaded delivery to be sent, got %d", len(rec.bodies))
+	}
+}
+
+// TestWebhook_Unregister tests removing a hook and its pending deliveries.
+func TestWebhook_Unregister(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDispatcher(t, t.TempDir())
+	hook, _ := d.Register(ctx, "http://example.com/hook", "", nil)
+	d.Enqueue(ctx, events.Event{ID: 1, Type: events.ItemCreated})
+
+	if err := d.Unregister(ctx, hook.ID); err != nil {
+		t.Fatalf("Unregister failed: %v", err)
+	}
+	if len(d.Hooks()) != 0 || len(d.Pending()) != 0 {
+		t.Errorf("Expected no hooks or pending deliveries")
+	}
+	if err := d.Unregister(ctx, hook.ID); err != ErrHookNotFound {
+		t.Errorf("Expected ErrHookNotFound, got %v", err)
+	}
+}
+
+// TestWebhook_RunDeliversBrokerEvents tests that Run forwards published events to hooks.
+func TestWebhook_RunDeliversBrokerEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec, server := newReceiver(t, 0)
+	d := newTestDispatcher(t, t.TempDir())
+	if _, err := d.Register(ctx, server.URL, "secret", nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	broker := events.NewBroker(10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx, broker)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		broker.Publish(events.ItemCreated, storage.Item{ID: 1})
+		time.Sleep(20 * time.Millisecond)
+		rec.mu.Lock()
+		delivered := len(rec.bodies)
+		rec.mu.Unlock()
+		if delivered > 0 {
+			return
+		}
+	}
+	t.Error("Expected broker event to be delivered")
+}
+
+// TestWebhook_RunQueuesEventsOnCancel tests that events published before cancellation stay queued for after a restart.
+func TestWebhook_RunQueuesEventsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, server := newReceiver(t, 1000)
+	folder := t.TempDir()
+	d := newTestDispatcher(t, folder)
+	d.BaseBackoff = time.Hour
+	if _, err := d.Register(ctx, server.URL, "secret", nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	broker := events.NewBroker(10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx, broker)
+	}()
+	// publish until Run has subscribed, the failing receiver keeps every delivery pending
+	for len(d.Pending()) == 0 {
+		broker.Publish(events.ItemCreated, storage.Item{ID: 1})
+		time.Sleep(time.Millisecond)
+	}
+	for i := 2; i <= 6; i++ {
+		broker.Publish(events.ItemCreated, storage.Item{ID: i})
+	}
+	cancel()
+	<-done
+
+	restarted := newTestDispatcher(t, folder)
+	queued := map[int]bool{}
+	for _, delivery := range restarted.Pending() {
+		queued[delivery.Event.Item.ID] = true
+	}
+	for i := 2; i <= 6; i++ {
+		if !queued[i] {
+			t.Errorf("Expected event for item %d to be queued after restart", i)
+		}
+	}
+
+}
+
+// TestWebhook_RunResubscribesAfterDrop tests that events published while the dispatcher is too slow
+// to read the feed are queued once it resubscribes.
+func TestWebhook_RunResubscribesAfterDrop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, server := newReceiver(t, 1000)
+	d := newTestDispatcher(t, t.TempDir())
+	d.BaseBackoff = time.Hour
+	if _, err := d.Register(ctx, server.URL, "secret", nil); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	broker := events.NewBroker(200)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx, broker)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	// publish until Run has subscribed, the failing receiver keeps every delivery pending
+	for len(d.Pending()) == 0 {
+		broker.Publish(events.ItemCreated, storage.Item{ID: 0})
+		time.Sleep(time.Millisecond)
+	}
+
+	// hold the dispatcher so its subscription overflows and is dropped
+	d.mu.Lock()
+	// more events than a subscriber queues
+	total := 100
+	for i := 1; i <= total; i++ {
+		broker.Publish(events.ItemCreated, storage.Item{ID: i})
+	}
+	d.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		queued := map[int]bool{}
+		for _, delivery := range d.Pending() {
+			queued[delivery.Event.Item.ID] = true
+		}
+		if len(queued) == total+1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected all %d events to be queued after the drop, got %d pending", total, len(d.Pending()))
+}
@@ -0,0 +1,359 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is the fixed GUID from RFC 6455 used to compute Sec-WebSocket-Accept.
+const websocketGUID string = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	TextMessage   int = 1
+	BinaryMessage int = 2
+	CloseMessage  int = 8
+	PingMessage   int = 9
+	PongMessage   int = 10
+
+	continuationFrame int = 0
+)
+
+const (
+	CloseNormal        int = 1000
+	CloseGoingAway     int = 1001
+	CloseProtocolError int = 1002
+	CloseTooLarge      int = 1009
+)
+
+// DefaultMaxMessageSize is the largest message a Conn accepts from a client.
+const DefaultMaxMessageSize int64 = 64 * 1024
+
+var (
+	ErrClosed          = errors.New("websocket: connection closed")
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+	ErrProtocol        = errors.New("websocket: protocol error")
+)
+
+type Conn struct {
+	conn           net.Conn
+	reader         *bufio.Reader
+	client         bool
+	writeMu        sync.Mutex
+	closeOnce      sync.Once
+	MaxMessageSize int64
+}
+
+// Upgrade performs the server side of the WebSocket opening handshake and hijacks the connection.
+// On failure an HTTP error response has already been written.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket: method not GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "Expected WebSocket upgrade", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "Invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: invalid key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket unsupported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	// complete the handshake
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: netConn, reader: rw.Reader, MaxMessageSize: DefaultMaxMessageSize}, nil
+}
+
+// Dial opens a client connection to a ws:// URL, mainly for tests and tools talking to /ws.
+func Dial(rawURL string, header http.Header) (*Conn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "ws" {
+		return nil, errors.New("websocket: only ws:// URLs are supported")
+	}
+	host := target.Host
+	if target.Port() == "" {
+		host = net.JoinHostPort(target.Hostname(), "80")
+	}
+	netConn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// send the opening handshake
+	var nonce [16]byte
+	_, _ = rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	request := &http.Request{
+		Method: http.MethodGet,
+		URL:    target,
+		Host:   target.Host,
+		Header: http.Header{},
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	if err := request.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	// verify the server accepted the upgrade
+	reader := bufio.NewReader(netConn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != AcceptKey(key) {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed with status %d", response.StatusCode)
+	}
+	return &Conn{conn: netConn, reader: reader, client: true, MaxMessageSize: DefaultMaxMessageSize}, nil
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept value for a client key.
+func AcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// ReadMessage reads the next complete data message, answering pings and close frames along the way.
+// It returns ErrClosed once the peer has closed the connection.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		messageType int
+		message     []byte
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			// echo the close frame then drop the connection
+			c.writeClose(payload)
+			c.Close()
+			return 0, nil, ErrClosed
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				c.CloseWithCode(CloseProtocolError, "expected continuation frame")
+				return 0, nil, ErrProtocol
+			}
+			messageType = opcode
+		case continuationFrame:
+			if messageType == 0 {
+				c.CloseWithCode(CloseProtocolError, "unexpected continuation frame")
+				return 0, nil, ErrProtocol
+			}
+		default:
+			c.CloseWithCode(CloseProtocolError, "unknown opcode")
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(message)+len(payload)) > c.MaxMessageSize {
+			c.CloseWithCode(CloseTooLarge, "message too large")
+			return 0, nil, ErrMessageTooLarge
+		}
+		message = append(message, payload...)
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+// WriteMessage writes a single unfragmented frame; it is safe to call from multiple goroutines.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	// only clients mask their frames
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	header := []byte{0x80 | byte(messageType)}
+	length := len(data)
+	switch {
+	case length <= 125:
+		header = append(header, maskBit|byte(length))
+	case length <= 0xFFFF:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	var frame []byte
+	if c.client {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		frame = append(header, mask[:]...)
+		for i, b := range data {
+			frame = append(frame, b^mask[i%4])
+		}
+	} else {
+		frame = append(header, data...)
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetReadDeadline sets the deadline for future ReadMessage calls.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// CloseWithCode sends a close frame with the given status code and reason, then closes the connection.
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	c.writeClose(payload)
+	return c.Close()
+}
+
+// Close closes the underlying network connection.
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// writeClose sends a close frame, ignoring errors as the connection is going away anyway.
+func (c *Conn) writeClose(payload []byte) {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = c.WriteMessage(CloseMessage, payload)
+}
+
+// readFrame reads and unmasks a single frame from the peer.
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.reader, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	opcode := int(head[0] & 0x0F)
+	masked := head[1]&0x80 != 0
+	length := int64(head[1] & 0x7F)
+
+	// reserved bits are only valid with negotiated extensions, which we do not support
+	if head[0]&0x70 != 0 {
+		c.CloseWithCode(CloseProtocolError, "reserved bits set")
+		return false, 0, nil, ErrProtocol
+	}
+	// clients must mask every frame and servers must not
+	if masked == c.client {
+		c.CloseWithCode(CloseProtocolError, "invalid frame masking")
+		return false, 0, nil, ErrProtocol
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	// control frames must be small and unfragmented
+	if opcode >= CloseMessage && (length > 125 || !fin) {
+		c.CloseWithCode(CloseProtocolError, "invalid control frame")
+		return false, 0, nil, ErrProtocol
+	}
+	if length < 0 || length > c.MaxMessageSize {
+		c.CloseWithCode(CloseTooLarge, "message too large")
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// headerContains reports whether a comma separated header contains the token, ignoring case.
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newEchoServer starts a test server that echoes every message back to the client.
+func newEchoServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, message); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestWebsocket_AcceptKey tests the accept key against the example from RFC 6455.
+func TestWebsocket_AcceptKey(t *testing.T) {
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected accept key: %s", got)
+	}
+}
+
+// TestWebsocket_Echo tests a round trip of small and large messages.
+func TestWebsocket_Echo(t *testing.T) {
+	server := newEchoServer(t)
+	conn, err := Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// cover the 7 bit, 16 bit and 64 bit length encodings
+	for _, size := range []int{5, 300, int(DefaultMaxMessageSize)} {
+		message := strings.Repeat("a", size)
+		if err := conn.WriteMessage(TextMessage, []byte(message)); err != nil {
+			t.Fatalf("WriteMessage failed: %v", err)
+		}
+		messageType, reply, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage failed: %v", err)
+		}
+		if messageType != TextMessage || string(reply) != message {
+			t.Errorf("Unexpected echo of %d bytes: type %d, %d bytes", len(message), messageType, len(reply))
+		}
+	}
+}
+
+// TestWebsocket_MessageTooLarge tests that the server closes on oversized messages.
+func TestWebsocket_MessageTooLarge(t *testing.T) {
+	server := newEchoServer(t)
+	conn, err := Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(TextMessage, make([]byte, DefaultMaxMessageSize+1)); err != nil {
+		t.Fatalf("WriteMessage failed: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+// TestWebsocket_UpgradeRejectsPlainRequest tests that non-upgrade requests are rejected.
+func TestWebsocket_UpgradeRejectsPlainRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/ws", nil)
+	w := httptest.NewRecorder()
+	if _, err := Upgrade(w, req); err == nil {
+		t.Fatal("Expected error for plain request")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
+
+// TestWebsocket_UpgradeRejectsBadVersion tests that unsupported protocol versions are rejected.
+func TestWebsocket_UpgradeRejectsBadVersion(t *testing.T) {
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	w := httptest.NewRecorder()
+	if _, err := Upgrade(w, req); err == nil {
+		t.Fatal("Expected error for unsupported version")
+	}
+	if w.Code != http.StatusUpgradeRequired {
+		t.Errorf("Expected 426, got %d", w.Code)
+	}
+}